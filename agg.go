@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spossner/gator/internal/database"
+	"github.com/spossner/gator/internal/rss"
+	"github.com/spossner/gator/internal/sanitize"
+	"github.com/spossner/gator/internal/schedule"
+	"github.com/spossner/gator/internal/urlnorm"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// aggStats counts the work done by all scrape workers of an agg run.
+type aggStats struct {
+	fetched     atomic.Int64
+	notModified atomic.Int64
+	failed      atomic.Int64
+	posts       atomic.Int64
+	updated     atomic.Int64
+}
+
+func (a *aggStats) String() string {
+	return fmt.Sprintf("fetched %d feed(s) (%d not modified, %d failed), stored %d new and updated %d post(s)",
+		a.fetched.Load(), a.notModified.Load(), a.failed.Load(), a.posts.Load(), a.updated.Load())
+}
+
+var errNoFeedDue = errors.New("no feed due")
+
+// scrapeFeeds claims the next due feed, stores its new posts and reschedules it.
+// errNoFeedDue is returned if no feed is due right now. Every fetch is recorded
+// in the fetch history of the feed.
+func scrapeFeeds(ctx context.Context, s *state, stats *aggStats) (err error) {
+	feed, err := s.db.ClaimNextFeedToFetch(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNoFeedDue
+	}
+	if err != nil {
+		return fmt.Errorf("error claiming next feed to fetch: %w", err)
+	}
+
+	fmt.Printf("scraping %s...\n", feed.Name)
+
+	started := time.Now()
+	history := database.CreateFeedFetchParams{FeedID: feed.ID}
+	defer func() {
+		// the history is written even if the fetch was aborted by a shutdown
+		recordFeedFetch(context.WithoutCancel(ctx), s, started, history, err)
+		if err != nil && ctx.Err() != nil {
+			releaseFeedClaim(context.WithoutCancel(ctx), s, feed)
+		}
+	}()
+
+	fetched, err := rss.FetchFeed(ctx, feed.Url, rss.CacheValidators{
+		ETag:         feed.Etag.String,
+		LastModified: feed.LastModified.String,
+	})
+	if err != nil {
+		var fetchErr *rss.FetchError
+		if errors.As(err, &fetchErr) {
+			history.StatusCode = sql.NullInt32{Int32: int32(fetchErr.StatusCode), Valid: true}
+			history.Bytes = sql.NullInt64{Int64: fetchErr.Bytes, Valid: true}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		stats.failed.Add(1)
+		if err := recordFeedFailure(ctx, s, feed, err); err != nil {
+			return err
+		}
+		return fmt.Errorf("error fetching feed %s: %w", feed.ID, err)
+	}
+	stats.fetched.Add(1)
+	if fetched.MovedTo != "" {
+		moveFeed(ctx, s, feed, fetched.MovedTo)
+	}
+	history.StatusCode = sql.NullInt32{Int32: int32(fetched.StatusCode), Valid: true}
+	history.Bytes = sql.NullInt64{Int64: fetched.Bytes, Valid: true}
+	history.ItemsSeen = int32(len(fetched.Items))
+	if fetched.NotModified {
+		stats.notModified.Add(1)
+		fmt.Printf("%s not modified\n", feed.Name)
+		// the hints of the last full fetch still apply
+		return rescheduleFeed(ctx, s, feed, 0, scheduleHints(feed))
+	}
+	for _, item := range fetched.Items {
+		// stop between two posts when shutting down; the cache validators are
+		// not stored so the feed is fetched completely next time
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if item.Link == "" {
+			fmt.Printf("skipping post %s without link\n", item.Title)
+			continue
+		}
+		// the cleaned url is used throughout - rotating tracking parameters
+		// must neither create new posts nor count as edits
+		link := postURL(item.Link, feed.Url)
+		guid := item.ID
+		if guid == "" {
+			guid = link
+		}
+		hash := contentHash(item, link)
+		err := s.db.AdoptPostGuid(ctx, database.AdoptPostGuidParams{
+			Guid:        guid,
+			ContentHash: hash,
+			FeedID:      feed.ID,
+			Urls:        []string{item.Link, link},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Printf("error persisting post %s: %v\n", item.Title, err)
+			continue
+		}
+		// the raw html is kept for reference, everything else works with the sanitized variant
+		post, err := s.db.UpsertPost(ctx, database.UpsertPostParams{
+			FeedID:         feed.ID,
+			Guid:           sql.NullString{String: guid, Valid: true},
+			Title:          item.Title,
+			Url:            link,
+			Description:    sql.NullString{String: sanitize.HTML(item.Description, link), Valid: true},
+			Content:        sql.NullString{String: sanitize.HTML(item.Content, link), Valid: item.Content != ""},
+			RawDescription: sql.NullString{String: item.Description, Valid: true},
+			RawContent:     sql.NullString{String: item.Content, Valid: item.Content != ""},
+			PublishedAt:    sql.NullTime{Time: item.Published, Valid: !item.Published.IsZero()},
+			ContentHash:    sql.NullString{String: hash, Valid: true},
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			history.Duplicates++
+		case err != nil:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Printf("error persisting post %s: %v\n", item.Title, err)
+		case post.Inserted:
+			history.PostsInserted++
+			stats.posts.Add(1)
+			fmt.Printf("* %s\n", post.Title)
+		default:
+			history.PostsUpdated++
+			stats.updated.Add(1)
+			fmt.Printf("* %s (updated)\n", post.Title)
+		}
+		if err == nil {
+			storeEnclosures(ctx, s, post.ID, item.Enclosures)
+		}
+	}
+
+	// store the validators only after the items are persisted - otherwise a
+	// failed run would never see the same content again
+	err = s.db.UpdateFeedCacheValidators(ctx, database.UpdateFeedCacheValidatorsParams{
+		ID:           feed.ID,
+		Etag:         sql.NullString{String: fetched.Validators.ETag, Valid: fetched.Validators.ETag != ""},
+		LastModified: sql.NullString{String: fetched.Validators.LastModified, Valid: fetched.Validators.LastModified != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("error storing cache validators of feed %s: %w", feed.ID, err)
+	}
+	err = s.db.UpdateFeedMetadata(ctx, database.UpdateFeedMetadataParams{
+		ID:          feed.ID,
+		Title:       optionalString(fetched.Title),
+		SiteUrl:     optionalString(fetched.Link),
+		Description: optionalString(fetched.Description),
+		ImageUrl:    optionalString(fetched.Image),
+	})
+	if err != nil {
+		return fmt.Errorf("error storing metadata of feed %s: %w", feed.ID, err)
+	}
+	err = s.db.UpdateFeedScheduleHints(ctx, database.UpdateFeedScheduleHintsParams{
+		ID:           feed.ID,
+		Ttl:          int32(fetched.Schedule.TTL.Seconds()),
+		UpdatePeriod: int32(fetched.Schedule.UpdatePeriod.Seconds()),
+		SkipHours:    toInt32s(fetched.Schedule.SkipHours),
+		SkipDays:     toInt32s(fetched.Schedule.SkipDays),
+	})
+	if err != nil {
+		return fmt.Errorf("error storing schedule hints of feed %s: %w", feed.ID, err)
+	}
+	return rescheduleFeed(ctx, s, feed, int(history.PostsInserted), fetched.Schedule)
+}
+
+// moveFeed stores the new url of a feed which was permanently redirected.
+// Failing to do so is reported but does not fail the fetch.
+func moveFeed(ctx context.Context, s *state, feed database.Feed, movedTo string) {
+	feedURL, err := urlnorm.Normalize(movedTo)
+	if err != nil {
+		fmt.Printf("error moving %s to %s: %v\n", feed.Name, movedTo, err)
+		return
+	}
+	canonicalURL, err := urlnorm.Key(feedURL)
+	if err != nil {
+		fmt.Printf("error moving %s to %s: %v\n", feed.Name, movedTo, err)
+		return
+	}
+	// a move which failed before is not retried on every fetch
+	if feedURL == feed.Url || feedURL == feed.MovedTo.String {
+		return
+	}
+	err = s.db.UpdateFeedUrl(ctx, database.UpdateFeedUrlParams{
+		ID:           feed.ID,
+		Url:          feedURL,
+		CanonicalUrl: canonicalURL,
+	})
+	if err != nil {
+		// most likely the new url is known as a feed of its own already
+		fmt.Printf("error moving %s to %s: %v\n", feed.Name, feedURL, err)
+		err = s.db.UpdateFeedMovedTo(ctx, database.UpdateFeedMovedToParams{
+			ID:      feed.ID,
+			MovedTo: optionalString(feedURL),
+		})
+		if err != nil {
+			fmt.Printf("error recording move of %s: %v\n", feed.Name, err)
+		}
+		return
+	}
+	fmt.Printf("%s moved permanently to %s\n", feed.Name, feedURL)
+}
+
+// postURL resolves relative post links against the feed url and removes
+// tracking parameters.
+func postURL(link, feedURL string) string {
+	if base, err := url.Parse(feedURL); err == nil {
+		if u, err := base.Parse(strings.TrimSpace(link)); err == nil {
+			link = u.String()
+		}
+	}
+	return urlnorm.StripTracking(link)
+}
+
+// optionalString maps empty strings to NULL.
+func optionalString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// releaseFeedClaim makes a feed whose fetch was aborted due right away instead
+// of waiting for the interval its claim scheduled it with.
+func releaseFeedClaim(ctx context.Context, s *state, feed database.Feed) {
+	if err := s.db.ReleaseFeedClaim(ctx, feed.ID); err != nil {
+		fmt.Printf("error releasing feed %s: %v\n", feed.ID, err)
+	}
+}
+
+// recordFeedFetch adds a fetch to the fetch history of its feed. Failing to do
+// so is reported but does not fail the fetch itself.
+func recordFeedFetch(ctx context.Context, s *state, started time.Time, history database.CreateFeedFetchParams, fetchErr error) {
+	history.DurationSeconds = time.Since(started).Seconds()
+	if fetchErr != nil {
+		history.Error = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+	if err := s.db.CreateFeedFetch(ctx, history); err != nil {
+		fmt.Printf("error recording fetch of feed %s: %v\n", history.FeedID, err)
+	}
+}
+
+// contentHash fingerprints the parts of an item which are stored in a post to
+// detect edited articles. link is the cleaned url of the item.
+func contentHash(item rss.Item, link string) string {
+	hash := sha256.New()
+	for _, part := range []string{item.Title, link, item.Description, item.Content} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	for _, enclosure := range item.Enclosures {
+		hash.Write([]byte(enclosure.URL))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func storeEnclosures(ctx context.Context, s *state, postID uuid.UUID, enclosures []rss.Enclosure) {
+	for _, enclosure := range enclosures {
+		err := s.db.UpsertEnclosure(ctx, database.UpsertEnclosureParams{
+			PostID:   postID,
+			Url:      enclosure.URL,
+			MimeType: sql.NullString{String: enclosure.Type, Valid: enclosure.Type != ""},
+			Length:   sql.NullInt64{Int64: enclosure.Length, Valid: enclosure.Length > 0},
+			Duration: sql.NullInt32{Int32: int32(enclosure.Duration.Seconds()), Valid: enclosure.Duration > 0},
+		})
+		if err != nil {
+			fmt.Printf("error persisting enclosure %s: %v\n", enclosure.URL, err)
+		}
+	}
+}
+
+// rescheduleFeed adapts the fetch interval of the feed to the number of new
+// posts and computes when the feed is due next.
+func rescheduleFeed(ctx context.Context, s *state, feed database.Feed, newPosts int, hints rss.Schedule) error {
+	current := time.Duration(feed.FetchInterval) * time.Second
+	interval := schedule.NextInterval(current, newPosts, hints)
+	now := time.Now()
+	next := schedule.NextFetch(now, interval, hints)
+	err := s.db.RecordFeedSuccess(ctx, database.RecordFeedSuccessParams{
+		ID:            feed.ID,
+		FetchInterval: int32(interval.Seconds()),
+		DelaySeconds:  next.Sub(now).Seconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("error rescheduling feed %s: %w", feed.ID, err)
+	}
+	return nil
+}
+
+// scheduleHints restores the publisher's polling hints stored with the feed.
+func scheduleHints(feed database.Feed) rss.Schedule {
+	hints := rss.Schedule{
+		TTL:          time.Duration(feed.Ttl) * time.Second,
+		UpdatePeriod: time.Duration(feed.UpdatePeriod) * time.Second,
+	}
+	for _, hour := range feed.SkipHours {
+		hints.SkipHours = append(hints.SkipHours, int(hour))
+	}
+	for _, day := range feed.SkipDays {
+		hints.SkipDays = append(hints.SkipDays, time.Weekday(day))
+	}
+	return hints
+}
+
+func toInt32s[T ~int](values []T) []int32 {
+	result := make([]int32, 0, len(values))
+	for _, v := range values {
+		result = append(result, int32(v))
+	}
+	return result
+}
+
+// recordFeedFailure stores the fetch error and retries the feed with
+// exponential backoff. Feeds failing too often in a row get disabled.
+func recordFeedFailure(ctx context.Context, s *state, feed database.Feed, fetchErr error) error {
+	failures := int(feed.FailureCount) + 1
+	disable := failures >= s.cfg.FeedFailureLimit()
+	delay := schedule.Backoff(time.Duration(feed.FetchInterval)*time.Second, failures)
+	err := s.db.RecordFeedFailure(ctx, database.RecordFeedFailureParams{
+		ID:           feed.ID,
+		LastError:    sql.NullString{String: fetchErr.Error(), Valid: true},
+		DelaySeconds: delay.Seconds(),
+		Disable:      disable,
+	})
+	if err != nil {
+		return fmt.Errorf("error recording failure of feed %s: %w", feed.ID, err)
+	}
+	if disable {
+		fmt.Printf("disabled %s after %d failures in a row\n", feed.Name, failures)
+	}
+	return nil
+}
+
+// scrapeWorker checks for due feeds every interval and scrapes them one after
+// another until no feed is due anymore, an error occurs or ctx is cancelled.
+func scrapeWorker(ctx context.Context, s *state, interval time.Duration, stats *aggStats) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			err := scrapeFeeds(ctx, s, stats)
+			if err == nil {
+				continue
+			}
+			// errors caused by the shutdown itself are not worth reporting
+			if !errors.Is(err, errNoFeedDue) && ctx.Err() == nil {
+				fmt.Println(err)
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func handlerAgg(s *state, cmd command) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	workers := flags.Int("workers", 1, "number of feeds scraped in parallel")
+	if err := parseFlags(flags, cmd.args, 1); err != nil {
+		return err
+	}
+
+	timeBetweenReqs := 30 * time.Second
+	if flags.NArg() > 0 {
+		d, err := time.ParseDuration(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid duration %s: %w", flags.Arg(0), err)
+		}
+		timeBetweenReqs = d
+	}
+
+	if timeBetweenReqs < 5*time.Second {
+		return fmt.Errorf("interval %s is too fast - min 5 seconds", timeBetweenReqs)
+	}
+	if *workers < 1 {
+		return fmt.Errorf("invalid number of workers %d - min 1", *workers)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// restore the default handling so a second signal quits a stuck shutdown
+		<-ctx.Done()
+		stop()
+		fmt.Println("\nshutting down - interrupt again to quit immediately")
+	}()
+
+	fmt.Printf("Collecting feeds every %v with %d worker(s)\n", timeBetweenReqs, *workers)
+
+	var stats aggStats
+	var wg sync.WaitGroup
+	for range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scrapeWorker(ctx, s, timeBetweenReqs, &stats)
+		}()
+	}
+	wg.Wait()
+
+	fmt.Printf("\nStopped collecting feeds: %s\n", &stats)
+	return nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spossner/gator/internal/database"
+	"github.com/spossner/gator/internal/render"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func handlerBrowse(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	all := flags.Bool("all", false, "show read posts as well")
+	folder := flags.String("folder", "", "show posts of the feeds in this folder only")
+	tag := flags.String("tag", "", "show posts with this tag only, read or not")
+	plain := flags.Bool("plain", false, "print post bodies without colors and styles")
+	width := flags.Int("width", terminalWidth(), "wrap post bodies at this many characters, 0 disables wrapping")
+	if err := parseFlags(flags, cmd.args, 1); err != nil {
+		return err
+	}
+	var limit int32 = 2
+	if flags.NArg() > 0 {
+		if i, err := strconv.Atoi(flags.Arg(0)); err == nil {
+			limit = int32(i)
+		}
+	}
+	opts := render.Options{Width: *width, ANSI: !*plain && colorsEnabled()}
+	// posts are usually tagged after reading them
+	unreadOnly := !*all && *tag == ""
+	tagID, err := tagFilter(s, user, *tag)
+	if err != nil {
+		return err
+	}
+	var folderID uuid.NullUUID
+	if *folder != "" {
+		folder, err := getFolderByName(s, user, *folder)
+		if err != nil {
+			return err
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	// posts are marked read as they are displayed - they stay part of the
+	// result for the rest of the session so paging back and forth works
+	var sessionReads []uuid.UUID
+
+	var page int32 = 0
+	fullContent := false
+	running := true
+	in := bufio.NewReader(os.Stdin)
+	for running {
+		posts, err := s.db.GetPostsByUser(context.Background(), database.GetPostsByUserParams{
+			UserID:       user.ID,
+			FolderID:     folderID,
+			TagID:        tagID,
+			UnreadOnly:   unreadOnly,
+			SessionReads: sessionReads,
+			Limit:        limit,
+			Offset:       page * limit,
+		})
+		if err != nil {
+			return fmt.Errorf("error fetching posts for user %s: %w", user.Name, err)
+		}
+		if len(posts) == 0 && page == 0 {
+			if unreadOnly {
+				fmt.Println("no unread posts - use --all to show read posts as well")
+			} else {
+				fmt.Println("no posts")
+			}
+			return nil
+		}
+		enclosures, err := getEnclosures(s, posts)
+		if err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, 0, len(posts))
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		tags, err := getTags(s, user, ids)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n\n>> PAGE %d <<\n\n", page+1)
+		for i, post := range posts {
+			// posts without a (parsable) publication date are shown with the time they were fetched
+			publishedAt := post.PublishedAt.Time
+			if !post.PublishedAt.Valid {
+				publishedAt = post.CreatedAt.Time
+			}
+			body := post.Description.String
+			if fullContent && post.Content.Valid || body == "" {
+				body = post.Content.String
+			}
+			details := ""
+			if post.Saved {
+				details += " | ★ saved"
+			}
+			if len(tags[post.ID]) > 0 {
+				details += " | tags: " + strings.Join(tags[post.ID], ", ")
+			}
+			opts.BaseURL = post.Url
+			fmt.Printf("%d) ** %s **\n%s\n%v | id %s%s\n---\n%s\n", i+1, strings.ToUpper(post.Name), post.Title, publishedAt.Format(time.DateTime), shortID(post.ID), details, render.HTML(body, opts))
+			printEnclosures(enclosures[post.ID])
+			fmt.Println()
+		}
+		if err := markRead(s, user, posts, &sessionReads); err != nil {
+			return err
+		}
+		toggle := "(f)ull content"
+		if fullContent {
+			toggle = "(s)ummary"
+		}
+		if page > 0 {
+			fmt.Printf("(q)uit | (n)ext | (p)revious | %s\n(*n) star/unstar post n | (tn label) tag post n | (un label) untag post n: ", toggle)
+		} else {
+			fmt.Printf("(q)uit | (n)ext | %s\n(*n) star/unstar post n | (tn label) tag post n | (un label) untag post n: ", toggle)
+		}
+		line, err := in.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		}
+		input := strings.TrimSpace(line)
+		if input == "" {
+			continue
+		}
+		switch input[0] {
+		case 'q':
+			running = false
+		case 'n':
+			page += 1
+		case 'p':
+			page = max(page-1, 0)
+		case 'f':
+			fullContent = true
+		case 's':
+			fullContent = false
+		case '*':
+			i, _, ok := pagePost(input[1:], len(posts))
+			if !ok {
+				fmt.Printf("choose a post between 1 and %d, e.g. *1\n", len(posts))
+				continue
+			}
+			if err := toggleSaved(s, user, posts[i-1].ID, posts[i-1].Saved); err != nil {
+				return err
+			}
+		case 't', 'u':
+			i, label, ok := pagePost(input[1:], len(posts))
+			if !ok || label == "" {
+				fmt.Printf("choose a post between 1 and %d and a label, e.g. %c1 to-review\n", len(posts), input[0])
+				continue
+			}
+			tagOrUntag := tagPost
+			if input[0] == 'u' {
+				tagOrUntag = untagPost
+			}
+			// a mistyped label is no reason to end browsing
+			if err := tagOrUntag(s, user, posts[i-1].ID, label); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// pagePost parses the number of a post on the current page followed by an
+// optional argument. The number may be omitted if the page shows one post only.
+func pagePost(input string, posts int) (int, string, bool) {
+	fields := strings.Fields(input)
+	if len(fields) > 0 {
+		if i, err := strconv.Atoi(fields[0]); err == nil {
+			return i, strings.Join(fields[1:], " "), i >= 1 && i <= posts
+		}
+	}
+	return 1, strings.Join(fields, " "), posts == 1
+}
+
+// toggleSaved stars the post or removes the star if it is saved already.
+func toggleSaved(s *state, user database.User, postID uuid.UUID, saved bool) error {
+	if saved {
+		if _, err := s.db.UnsavePost(context.Background(), database.UnsavePostParams{UserID: user.ID, PostID: postID}); err != nil {
+			return fmt.Errorf("error removing post %s from saved posts: %w", shortID(postID), err)
+		}
+		return nil
+	}
+	if err := s.db.SavePost(context.Background(), database.SavePostParams{UserID: user.ID, PostID: postID}); err != nil {
+		return fmt.Errorf("error saving post %s: %w", shortID(postID), err)
+	}
+	return nil
+}
+
+// markRead marks the displayed posts read and remembers them as read in this session.
+func markRead(s *state, user database.User, posts []database.GetPostsByUserRow, sessionReads *[]uuid.UUID) error {
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		if !slices.Contains(*sessionReads, post.ID) {
+			ids = append(ids, post.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	err := s.db.MarkPostsRead(context.Background(), database.MarkPostsReadParams{
+		UserID:  user.ID,
+		PostIds: ids,
+	})
+	if err != nil {
+		return fmt.Errorf("error marking posts read: %w", err)
+	}
+	*sessionReads = append(*sessionReads, ids...)
+	return nil
+}
+
+// getEnclosures loads the enclosures of the given posts grouped by post.
+func getEnclosures(s *state, posts []database.GetPostsByUserRow) (map[uuid.UUID][]database.Enclosure, error) {
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	enclosures, err := s.db.GetEnclosuresByPosts(context.Background(), ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching attachments: %w", err)
+	}
+	byPost := make(map[uuid.UUID][]database.Enclosure, len(posts))
+	for _, enclosure := range enclosures {
+		byPost[enclosure.PostID] = append(byPost[enclosure.PostID], enclosure)
+	}
+	return byPost, nil
+}
+
+func printEnclosures(enclosures []database.Enclosure) {
+	if len(enclosures) == 0 {
+		return
+	}
+	fmt.Println("attachments:")
+	for _, enclosure := range enclosures {
+		var details []string
+		if enclosure.MimeType.Valid {
+			details = append(details, enclosure.MimeType.String)
+		}
+		if enclosure.Length.Valid {
+			details = append(details, formatBytes(enclosure.Length.Int64))
+		}
+		if enclosure.Duration.Valid {
+			details = append(details, (time.Duration(enclosure.Duration.Int32) * time.Second).String())
+		}
+		fmt.Printf("  - %s", enclosure.Url)
+		if len(details) > 0 {
+			fmt.Printf(" (%s)", strings.Join(details, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+// terminalWidth returns the width of the terminal as announced by the shell in
+// COLUMNS or 80 characters if it is unknown.
+func terminalWidth() int {
+	if i, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && i > 0 {
+		return i
+	}
+	return 80
+}
+
+// colorsEnabled reports whether stdout is a terminal and the user did not opt
+// out of colors with NO_COLOR.
+func colorsEnabled() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// shortID is the abbreviated post id shown to users. Commands accept it as
+// well as any longer prefix of the id.
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -5,11 +5,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/spossner/gator/internal/database"
+	"github.com/spossner/gator/internal/download"
 	"github.com/spossner/gator/internal/rss"
-	"io"
+	"github.com/spossner/gator/internal/urlnorm"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -39,6 +45,24 @@ func (c *commands) run(s *state, cmd command) error {
 	return fn(s, cmd)
 }
 
+// parseFlags parses the flags of a command allowing at most maxArgs positional
+// arguments. The flag package stops at the first positional argument, so flags
+// given after it are rejected instead of being silently ignored.
+func parseFlags(flags *flag.FlagSet, args []string, maxArgs int) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for _, arg := range flags.Args() {
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			return fmt.Errorf("flag %s must be given before %s", arg, flags.Arg(0))
+		}
+	}
+	if flags.NArg() > maxArgs {
+		return fmt.Errorf("unexpected argument(s): %s", strings.Join(flags.Args()[maxArgs:], " "))
+	}
+	return nil
+}
+
 func handlerLogin(s *state, cmd command) error {
 	if len(cmd.args) == 0 {
 		return errors.New("missing username")
@@ -96,118 +120,421 @@ func handlerUsers(s *state, cmd command) error {
 	return nil
 }
 
-func scrapeFeeds(s *state) error {
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
+func handlerAddFeed(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	skipValidation := flags.Bool("skip-validation", false, "store the url as given without fetching the feed")
+	if err := parseFlags(flags, cmd.args, 2); err != nil {
+		return err
+	}
+	var name, feedURL string
+	switch flags.NArg() {
+	case 0:
+		return errors.New("missing url")
+	case 1:
+		feedURL = flags.Arg(0)
+	default:
+		name, feedURL = flags.Arg(0), flags.Arg(1)
+	}
+	// adds the default scheme - urls like example.com cannot be fetched otherwise
+	feedURL, err := urlnorm.Normalize(feedURL)
+	if err != nil {
+		return err
+	}
+
+	// without validation the metadata stays empty until the first agg run
+	fetched := &rss.Feed{}
+	if !*skipValidation {
+		if feedURL, err = discoverFeed(feedURL); err != nil {
+			return err
+		}
+		if fetched, err = validateFeed(feedURL); err != nil {
+			return err
+		}
+		if fetched.MovedTo != "" {
+			fmt.Printf("feed moved permanently to %s\n", fetched.MovedTo)
+			feedURL = fetched.MovedTo
+		}
+	}
+	// discovered and redirected urls are normalized as well
+	if feedURL, err = urlnorm.Normalize(feedURL); err != nil {
+		return err
+	}
+	canonicalURL, err := urlnorm.Key(feedURL)
+	if err != nil {
+		return err
+	}
+	if existing, err := getFeedByUrl(s, feedURL); err == nil {
+		return fmt.Errorf("feed %s already exists as %s (%s) - follow it instead", feedURL, existing.Name, existing.Url)
+	}
+	if name == "" {
+		name = feedName(fetched, feedURL)
+	}
+
+	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
+		Name:         name,
+		Url:          feedURL,
+		CanonicalUrl: canonicalURL,
+		UserID:       user.ID,
+		Title:        optionalString(fetched.Title),
+		SiteUrl:      optionalString(fetched.Link),
+		Description:  optionalString(fetched.Description),
+		ImageUrl:     optionalString(fetched.Image),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating feed entry: %w", err)
+	}
+
+	fmt.Printf("created feed %s\n", feed.Name)
+
+	follow, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
 	if err != nil {
-		return fmt.Errorf("error identifying next feed to fetch: %w", err)
+		return fmt.Errorf("error following the new feed %s: %w", feed.Name, err)
 	}
 
-	fmt.Printf("scraping %s...\n", feed.Name)
+	fmt.Printf("%s is now following %s\n", follow.UserName, follow.FeedName)
+
+	return nil
+}
 
-	err = s.db.MarkFeedFetched(context.Background(), feed.ID)
+// getFeedByUrl finds a feed by any spelling of its url, e.g. with or without
+// www. or a trailing slash.
+func getFeedByUrl(s *state, feedURL string) (database.Feed, error) {
+	key, err := urlnorm.Key(feedURL)
 	if err != nil {
-		return fmt.Errorf("error marking feed %s as fetched: %w", feed.ID, err)
+		key = feedURL
 	}
-	rssFeed, err := rss.FetchFeed(context.Background(), feed.Url)
+	return s.db.GetFeedByUrl(context.Background(), database.GetFeedByUrlParams{
+		CanonicalUrl: key,
+		Url:          feedURL,
+	})
+}
+
+// validateFeed fetches the feed and reports what was found. Urls which do not
+// serve a parseable feed are rejected.
+func validateFeed(feedURL string) (*rss.Feed, error) {
+	fetched, err := rss.FetchFeed(context.Background(), feedURL, rss.CacheValidators{})
 	if err != nil {
-		return fmt.Errorf("error fetching feed %s: %w", feed.ID, err)
+		return nil, fmt.Errorf("%s is not a valid feed: %w", feedURL, err)
 	}
-	for _, item := range rssFeed.Channel.Item {
-		pubDate, err := time.Parse("Mon, 02 Jan 2006 15:04:05 +0000", item.PubDate)
-		if err != nil {
-			return err
+
+	var newest time.Time
+	for _, item := range fetched.Items {
+		if item.Published.After(newest) {
+			newest = item.Published
 		}
-		post, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
-			FeedID:      feed.ID,
-			Title:       item.Title,
-			Url:         item.Link,
-			Description: sql.NullString{String: item.Description, Valid: true},
-			PublishedAt: sql.NullTime{Time: pubDate, Valid: true},
-		})
-		if err != nil {
-			if !strings.HasPrefix(err.Error(), "pq: duplicate key") {
-				fmt.Printf("error persisting post %s: %v\n", item.Title, err)
-			}
-		} else {
-			fmt.Printf("* %s\n", post.Title)
+	}
+	fmt.Printf("found %s feed with %d item(s)", fetched.Format, len(fetched.Items))
+	if !newest.IsZero() {
+		fmt.Printf(", newest from %s", newest.Format(time.DateTime))
+	}
+	fmt.Println()
+	return fetched, nil
+}
+
+// feedName names a feed after its title, falling back to the host serving it.
+func feedName(feed *rss.Feed, feedURL string) string {
+	if title := strings.Join(strings.Fields(feed.Title), " "); title != "" {
+		return title
+	}
+	if u, err := url.Parse(feedURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return feedURL
+}
+
+// discoverFeed finds the feed of the website at pageURL. The user chooses
+// one if the website offers several feeds.
+func discoverFeed(pageURL string) (string, error) {
+	candidates, err := rss.Discover(context.Background(), pageURL)
+	if err != nil {
+		return "", fmt.Errorf("error discovering feeds at %s: %w", pageURL, err)
+	}
+	if len(candidates) == 1 {
+		if candidates[0].URL != pageURL {
+			fmt.Printf("found feed %s\n", candidates[0].URL)
+		}
+		return candidates[0].URL, nil
+	}
+
+	fmt.Printf("%s offers %d feeds:\n", pageURL, len(candidates))
+	for i, candidate := range candidates {
+		fmt.Printf("%d) %s", i+1, candidate.URL)
+		if candidate.Title != "" {
+			fmt.Printf(" - %s", candidate.Title)
 		}
+		fmt.Printf(" (%s)\n", candidate.Type)
+	}
+	fmt.Printf("choose a feed [1-%d]: ", len(candidates))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no feed chosen")
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || i < 1 || i > len(candidates) {
+		return "", fmt.Errorf("invalid choice %s", strings.TrimSpace(line))
+	}
+	return candidates[i-1].URL, nil
+}
+
+func handlerFeeds(s *state, cmd command) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	broken := flags.Bool("broken", false, "list failing and disabled feeds only")
+	if err := parseFlags(flags, cmd.args, 0); err != nil {
+		return err
+	}
+	if *broken {
+		return listBrokenFeeds(s)
+	}
+
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("error fetching feeds: %w", err)
+	}
+	for _, feed := range feeds {
+		fmt.Printf("* %s (%s), %s\n", feed.Name, feed.Url, feed.UserName)
 	}
 	return nil
 }
 
-func handlerAgg(s *state, cmd command) error {
-	timeBetweenReqs := 30 * time.Second
-	if len(cmd.args) > 0 {
-		d, err := time.ParseDuration(cmd.args[0])
-		if err != nil {
-			return fmt.Errorf("invalid duration %s: %w", cmd.args[0], err)
+func listBrokenFeeds(s *state) error {
+	feeds, err := s.db.GetBrokenFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("error fetching broken feeds: %w", err)
+	}
+	for _, feed := range feeds {
+		fmt.Printf("* %s (%s), %s\n", feed.Name, feed.Url, feed.UserName)
+		if feed.DisabledAt.Valid {
+			fmt.Printf("  disabled since %s\n", feed.DisabledAt.Time.Format(time.DateTime))
+		}
+		lastSuccess := "never"
+		if feed.LastSuccessAt.Valid {
+			lastSuccess = feed.LastSuccessAt.Time.Format(time.DateTime)
 		}
-		timeBetweenReqs = d
+		fmt.Printf("  %d failure(s) in a row, last success: %s\n", feed.FailureCount, lastSuccess)
+		fmt.Printf("  last error: %s\n", feed.LastError.String)
 	}
+	return nil
+}
 
-	if timeBetweenReqs < 5*time.Second {
-		return fmt.Errorf("interval %s is too fast - min 5 seconds", timeBetweenReqs)
+func handlerFeedHistory(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return errors.New("missing feed url")
+	}
+	var limit int32 = 10
+	if len(cmd.args) > 1 {
+		if i, err := strconv.Atoi(cmd.args[1]); err == nil {
+			limit = int32(i)
+		}
 	}
 
-	fmt.Printf("Collecting feeds every %v\n", timeBetweenReqs)
+	url := cmd.args[0]
+	feed, err := getFeedByUrl(s, url)
+	if err != nil {
+		return fmt.Errorf("unknown feed %s: %w", url, err)
+	}
 
-	ticker := time.NewTicker(timeBetweenReqs)
-	defer ticker.Stop()
-	for range ticker.C {
-		err := scrapeFeeds(s)
-		if err != nil {
-			fmt.Println(err)
+	fetches, err := s.db.GetFeedFetches(context.Background(), database.GetFeedFetchesParams{
+		FeedID: feed.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching history of feed %s: %w", url, err)
+	}
+
+	lastSuccess := "never"
+	if feed.LastSuccessAt.Valid {
+		lastSuccess = feed.LastSuccessAt.Time.Format(time.DateTime)
+	}
+	fmt.Printf("%s (%s)\nlast success: %s\n\n", feed.Name, feed.Url, lastSuccess)
+
+	for _, fetch := range fetches {
+		status := "-"
+		if fetch.StatusCode.Valid {
+			status = strconv.Itoa(int(fetch.StatusCode.Int32))
+		}
+		fmt.Printf("* %s  status %s  %d bytes  %v  items %d, new %d, updated %d, duplicates %d\n",
+			fetch.StartedAt.Format(time.DateTime),
+			status,
+			fetch.Bytes.Int64,
+			fetch.FinishedAt.Sub(fetch.StartedAt).Round(time.Millisecond),
+			fetch.ItemsSeen,
+			fetch.PostsInserted,
+			fetch.PostsUpdated,
+			fetch.Duplicates,
+		)
+		if fetch.Error.Valid {
+			fmt.Printf("  error: %s\n", fetch.Error.String)
 		}
 	}
+
 	return nil
 }
 
-func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.args) < 2 {
-		return errors.New("missing name + url")
+// getPostByPrefix finds the post with the given id or unique id prefix.
+func getPostByPrefix(s *state, prefix string) (database.Post, error) {
+	posts, err := s.db.GetPostsByIdPrefix(context.Background(), strings.ToLower(prefix))
+	if err != nil {
+		return database.Post{}, fmt.Errorf("error fetching post %s: %w", prefix, err)
 	}
-	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
-		Name:   cmd.args[0],
-		Url:    cmd.args[1],
-		UserID: user.ID,
-	})
+	switch len(posts) {
+	case 0:
+		return database.Post{}, fmt.Errorf("unknown post %s", prefix)
+	case 1:
+		return posts[0], nil
+	}
+	return database.Post{}, fmt.Errorf("post id %s is ambiguous - use more characters", prefix)
+}
+
+func handlerDownload(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return errors.New("missing post id")
+	}
+	post, err := getPostByPrefix(s, cmd.args[0])
 	if err != nil {
-		return fmt.Errorf("error creating feed entry: %w", err)
+		return err
 	}
 
-	fmt.Printf("created feed %s\n", feed.Name)
+	dir, err := s.cfg.DownloadDirectory()
+	if err != nil {
+		return err
+	}
+	if len(cmd.args) > 1 {
+		dir = cmd.args[1]
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating download directory %s: %w", dir, err)
+	}
 
-	follow, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
-		UserID: user.ID,
-		FeedID: feed.ID,
-	})
+	enclosures, err := s.db.GetEnclosuresByPosts(context.Background(), []uuid.UUID{post.ID})
 	if err != nil {
-		return fmt.Errorf("error following the new feed %s: %w", feed.Name, err)
+		return fmt.Errorf("error fetching attachments of post %s: %w", post.Title, err)
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("post %s has no attachments", post.Title)
 	}
 
-	fmt.Printf("%s is now following %s\n", follow.UserName, follow.FeedName)
+	for i, enclosure := range enclosures {
+		path := filepath.Join(dir, enclosureFileName(post, enclosure, i))
+		if download.Exists(path) {
+			fmt.Printf("%s already downloaded\n", path)
+			continue
+		}
+		fmt.Printf("downloading %s...\n", enclosure.Url)
+		result, err := download.File(context.Background(), enclosure.Url, path)
+		if err != nil {
+			return err
+		}
+		resumed := ""
+		if result.Resumed {
+			resumed = " (resumed)"
+		}
+		fmt.Printf("saved %s, %s%s\n", result.Path, formatBytes(result.Size), resumed)
+	}
 
 	return nil
 }
 
-func handlerFeeds(s *state, _ command) error {
-	feeds, err := s.db.GetFeeds(context.Background())
+// enclosureFileName derives the local file name from the url of the enclosure.
+// The name is prefixed with the post id because many podcast hosts use the same
+// generic name like audio.mp3 for every episode.
+func enclosureFileName(post database.Post, enclosure database.Enclosure, i int) string {
+	prefix := shortID(post.ID)
+	if i > 0 {
+		prefix = fmt.Sprintf("%s-%d", prefix, i+1)
+	}
+	if u, err := url.Parse(enclosure.Url); err == nil {
+		name := path.Base(u.Path)
+		if name != "." && name != "/" {
+			return prefix + "-" + name
+		}
+	}
+	return prefix
+}
+
+func handlerEnableFeed(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return errors.New("missing feed url")
+	}
+
+	url := cmd.args[0]
+	feed, err := getFeedByUrl(s, url)
 	if err != nil {
-		return fmt.Errorf("error fetching feeds: %w", err)
+		return fmt.Errorf("unknown feed %s: %w", url, err)
 	}
-	for _, feed := range feeds {
-		fmt.Printf("* %s (%s), %s\n", feed.Name, feed.Url, feed.UserName)
+
+	feed, err = s.db.EnableFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("error enabling feed %s: %w", url, err)
+	}
+
+	fmt.Printf("enabled feed %s - it gets fetched with the next agg run\n", feed.Name)
+
+	return nil
+}
+
+func handlerMarkRead(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	olderThan := flags.String("older-than", "", "only mark posts older than this age read, e.g. 7d or 12h")
+	if err := parseFlags(flags, cmd.args, 1); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 && *olderThan == "" {
+		return errors.New("missing feed url or all")
 	}
+
+	params := database.MarkFollowedPostsReadParams{UserID: user.ID}
+	if *olderThan != "" {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			return err
+		}
+		params.OlderThanSeconds = sql.NullFloat64{Float64: age.Seconds(), Valid: true}
+	}
+	if flags.NArg() > 0 && flags.Arg(0) != "all" {
+		url := flags.Arg(0)
+		feed, err := getFeedByUrl(s, url)
+		if err != nil {
+			return fmt.Errorf("unknown feed %s: %w", url, err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	n, err := s.db.MarkFollowedPostsRead(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("error marking posts read: %w", err)
+	}
+	fmt.Printf("marked %d post(s) read\n", n)
 	return nil
 }
 
+// parseAge parses durations like time.ParseDuration and additionally accepts
+// days (d) and weeks (w), e.g. 7d.
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, found := strings.CutSuffix(s, suffix); found {
+			if i, err := strconv.Atoi(n); err == nil && i >= 0 {
+				return time.Duration(i) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %s - use e.g. 7d, 2w or 12h", s)
+	}
+	return d, nil
+}
+
 func handlerFollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) == 0 {
 		return errors.New("missing feed url")
 	}
 
 	url := cmd.args[0]
-	feed, err := s.db.GetFeedByUrl(context.Background(), url)
+	feed, err := getFeedByUrl(s, url)
 	if err != nil {
 		return fmt.Errorf("unknown feed %s: %w", url, err)
 	}
@@ -226,13 +553,23 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 }
 
 func handlerFollowing(s *state, _ command, user database.User) error {
-	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{UserID: user.ID})
 	if err != nil {
 		return fmt.Errorf("error fetching follows for user %s: %w", user.Name, err)
 	}
 
+	// follows are ordered by folder, unfiled feeds first
+	folder := ""
 	for _, follow := range follows {
-		fmt.Printf("* %s: %s\n", follow.FeedName, follow.FeedUrl)
+		if follow.FolderName.String != folder {
+			folder = follow.FolderName.String
+			fmt.Printf("\n%s/\n", folder)
+		}
+		indent := ""
+		if folder != "" {
+			indent = "  "
+		}
+		fmt.Printf("%s* %s: %s\n", indent, follow.FeedName, follow.FeedUrl)
 	}
 
 	return nil
@@ -244,7 +581,7 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	}
 
 	url := cmd.args[0]
-	feed, err := s.db.GetFeedByUrl(context.Background(), url)
+	feed, err := getFeedByUrl(s, url)
 	if err != nil {
 		return fmt.Errorf("error fetching feed %s: %w", url, err)
 	}
@@ -261,49 +598,3 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 
 	return nil
 }
-
-func handlerBrowse(s *state, cmd command, user database.User) error {
-	var limit int32 = 2
-	if len(cmd.args) > 0 {
-		if i, err := strconv.Atoi(cmd.args[0]); err == nil {
-			limit = int32(i)
-		}
-	}
-
-	var page int32 = 0
-	running := true
-	for running {
-		posts, err := s.db.GetPostsByUser(context.Background(), database.GetPostsByUserParams{
-			UserID: user.ID,
-			Limit:  limit,
-			Offset: page * limit,
-		})
-		if err != nil {
-			return fmt.Errorf("error fetching posts for user %s: %w", user.Name, err)
-		}
-		fmt.Printf("\n\n>> PAGE %d <<\n\n", page+1)
-		for _, post := range posts {
-			fmt.Printf("** %s **\n%s\n%v\n---\n%s\n\n", strings.ToUpper(post.Name), post.Title, post.PublishedAt.Time.Format(time.DateTime), strings.TrimSpace(post.Description.String))
-		}
-		if page > 0 {
-			fmt.Print("(q)uit | (n)ext | (p)revious: ")
-		} else {
-			fmt.Print("(q)uit | (n)ext: ")
-		}
-		in := bufio.NewReader(os.Stdin)
-		c, err := in.ReadByte()
-		if err == io.EOF {
-			break
-		}
-		switch c {
-		case 'q':
-			running = false
-		case 'n':
-			page += 1
-		case 'p':
-			page = max(page-1, 0)
-		}
-	}
-
-	return nil
-}
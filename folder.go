@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spossner/gator/internal/database"
+	"strings"
+)
+
+// handlerFolder manages the folders feeds are filed in. The first argument
+// selects the sub command.
+func handlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return listFolders(s, user)
+	}
+	args := cmd.args[1:]
+	switch cmd.args[0] {
+	case "list":
+		return listFolders(s, user)
+	case "add":
+		return addFolder(s, user, args)
+	case "rename":
+		return renameFolder(s, user, args)
+	case "delete":
+		return deleteFolder(s, user, args)
+	case "move":
+		return moveToFolder(s, user, args)
+	}
+	return fmt.Errorf("unknown folder command %s - use list, add, rename, delete or move", cmd.args[0])
+}
+
+func listFolders(s *state, user database.User) error {
+	folders, err := s.db.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error fetching folders for user %s: %w", user.Name, err)
+	}
+	for _, folder := range folders {
+		fmt.Printf("* %s (%d feed(s))\n", folder.Name, folder.FeedCount)
+	}
+	return nil
+}
+
+func addFolder(s *state, user database.User, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing folder name")
+	}
+	name, err := folderName(args[0])
+	if err != nil {
+		return err
+	}
+	folder, err := s.db.CreateFolder(context.Background(), database.CreateFolderParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating folder %s: %w", name, err)
+	}
+	fmt.Printf("created folder %s\n", folder.Name)
+	return nil
+}
+
+func renameFolder(s *state, user database.User, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing folder name + new name")
+	}
+	newName, err := folderName(args[1])
+	if err != nil {
+		return err
+	}
+	folder, err := s.db.RenameFolder(context.Background(), database.RenameFolderParams{
+		UserID:  user.ID,
+		Name:    args[0],
+		NewName: newName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("unknown folder %s", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error renaming folder %s: %w", args[0], err)
+	}
+	fmt.Printf("renamed folder %s to %s\n", args[0], folder.Name)
+	return nil
+}
+
+func deleteFolder(s *state, user database.User, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing folder name")
+	}
+	n, err := s.db.DeleteFolder(context.Background(), database.DeleteFolderParams{
+		UserID: user.ID,
+		Name:   args[0],
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting folder %s: %w", args[0], err)
+	}
+	if n == 0 {
+		return fmt.Errorf("unknown folder %s", args[0])
+	}
+	fmt.Printf("deleted folder %s - its feeds are unfiled now\n", args[0])
+	return nil
+}
+
+// moveToFolder files a followed feed in a folder. Without a folder the feed is
+// removed from its folder.
+func moveToFolder(s *state, user database.User, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing feed url + folder name")
+	}
+	url := args[0]
+	feed, err := getFeedByUrl(s, url)
+	if err != nil {
+		return fmt.Errorf("unknown feed %s: %w", url, err)
+	}
+
+	var folderID uuid.NullUUID
+	if len(args) > 1 {
+		folder, err := getFolderByName(s, user, args[1])
+		if err != nil {
+			return err
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	n, err := s.db.MoveFeedFollow(context.Background(), database.MoveFeedFollowParams{
+		UserID:   user.ID,
+		FeedID:   feed.ID,
+		FolderID: folderID,
+	})
+	if err != nil {
+		return fmt.Errorf("error moving feed %s: %w", url, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("you are not following %s", url)
+	}
+	if len(args) > 1 {
+		fmt.Printf("moved %s to %s\n", feed.Name, args[1])
+	} else {
+		fmt.Printf("removed %s from its folder\n", feed.Name)
+	}
+	return nil
+}
+
+func getFolderByName(s *state, user database.User, name string) (database.Folder, error) {
+	folder, err := s.db.GetFolderByName(context.Background(), database.GetFolderByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Folder{}, fmt.Errorf("unknown folder %s - create it with folder add", name)
+	}
+	if err != nil {
+		return database.Folder{}, fmt.Errorf("error fetching folder %s: %w", name, err)
+	}
+	return folder, nil
+}
+
+func folderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("empty folder name")
+	}
+	return name, nil
+}
@@ -4,13 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 )
 
 const configFileName = ".config/gator/config.json"
 
+const defaultMaxFeedFailures = 10
+
 type Config struct {
 	DBUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	MaxFeedFailures int    `json:"max_feed_failures,omitempty"`
+	DownloadDir     string `json:"download_dir,omitempty"`
 }
 
 func (c *Config) SetUser(userName string) error {
@@ -21,6 +26,31 @@ func (c *Config) SetUser(userName string) error {
 	return nil
 }
 
+// FeedFailureLimit returns the number of consecutive failures after which a feed gets disabled.
+func (c *Config) FeedFailureLimit() int {
+	if c.MaxFeedFailures <= 0 {
+		return defaultMaxFeedFailures
+	}
+	return c.MaxFeedFailures
+}
+
+// DownloadDirectory returns the directory attachments are downloaded to.
+// A leading ~ is expanded to the home directory of the user.
+func (c *Config) DownloadDirectory() (string, error) {
+	dir := c.DownloadDir
+	if dir == "" {
+		return ".", nil
+	}
+	if rest, found := strings.CutPrefix(dir, "~"); found {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error retrieving user home directory: %w", err)
+		}
+		dir = home + rest
+	}
+	return dir, nil
+}
+
 func (c *Config) String() string {
 	data, err := json.Marshal(*c)
 	if err != nil {
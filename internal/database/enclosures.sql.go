@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: enclosures.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getEnclosuresByPosts = `-- name: GetEnclosuresByPosts :many
+SELECT id, post_id, url, mime_type, length, duration, created_at, updated_at
+FROM enclosures
+WHERE post_id = ANY($1::uuid[])
+ORDER BY created_at
+`
+
+func (q *Queries) GetEnclosuresByPosts(ctx context.Context, postIds []uuid.UUID) ([]Enclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getEnclosuresByPosts, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Enclosure
+	for rows.Next() {
+		var i Enclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.Url,
+			&i.MimeType,
+			&i.Length,
+			&i.Duration,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertEnclosure = `-- name: UpsertEnclosure :exec
+INSERT INTO enclosures (post_id, url, mime_type, length, duration)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (post_id, url) DO UPDATE
+SET mime_type = excluded.mime_type,
+    length = excluded.length,
+    duration = excluded.duration,
+    updated_at = current_timestamp
+`
+
+type UpsertEnclosureParams struct {
+	PostID   uuid.UUID
+	Url      string
+	MimeType sql.NullString
+	Length   sql.NullInt64
+	Duration sql.NullInt32
+}
+
+func (q *Queries) UpsertEnclosure(ctx context.Context, arg UpsertEnclosureParams) error {
+	_, err := q.db.ExecContext(ctx, upsertEnclosure,
+		arg.PostID,
+		arg.Url,
+		arg.MimeType,
+		arg.Length,
+		arg.Duration,
+	)
+	return err
+}
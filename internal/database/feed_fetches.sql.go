@@ -0,0 +1,104 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: feed_fetches.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createFeedFetch = `-- name: CreateFeedFetch :exec
+INSERT INTO feed_fetches (feed_id, started_at, status_code, bytes, items_seen, posts_inserted, posts_updated, duplicates, error)
+VALUES (
+    $1,
+    current_timestamp - make_interval(secs => $2::float8),
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+`
+
+type CreateFeedFetchParams struct {
+	FeedID          uuid.UUID
+	DurationSeconds float64
+	StatusCode      sql.NullInt32
+	Bytes           sql.NullInt64
+	ItemsSeen       int32
+	PostsInserted   int32
+	PostsUpdated    int32
+	Duplicates      int32
+	Error           sql.NullString
+}
+
+// The fetch is recorded when it is finished, its start is derived from the duration.
+func (q *Queries) CreateFeedFetch(ctx context.Context, arg CreateFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, createFeedFetch,
+		arg.FeedID,
+		arg.DurationSeconds,
+		arg.StatusCode,
+		arg.Bytes,
+		arg.ItemsSeen,
+		arg.PostsInserted,
+		arg.PostsUpdated,
+		arg.Duplicates,
+		arg.Error,
+	)
+	return err
+}
+
+const getFeedFetches = `-- name: GetFeedFetches :many
+SELECT id, feed_id, started_at, finished_at, status_code, bytes, items_seen, posts_inserted, duplicates, error, posts_updated
+FROM feed_fetches
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2
+`
+
+type GetFeedFetchesParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetFeedFetches(ctx context.Context, arg GetFeedFetchesParams) ([]FeedFetch, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFetches, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedFetch
+	for rows.Next() {
+		var i FeedFetch
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.StatusCode,
+			&i.Bytes,
+			&i.ItemsSeen,
+			&i.PostsInserted,
+			&i.Duplicates,
+			&i.Error,
+			&i.PostsUpdated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -14,12 +14,12 @@ import (
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH ff as (
-    INSERT INTO feed_follows (user_id, feed_id)
-    VALUES ($1, $2)
-    RETURNING id, user_id, feed_id, created_at, updated_at
+    INSERT INTO feed_follows (user_id, feed_id, folder_id)
+    VALUES ($1, $2, $3)
+    RETURNING id, user_id, feed_id, created_at, updated_at, folder_id
 )
 SELECT
-    ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
+    ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at, ff.folder_id,
     f.name as feed_name,
     f.url as feed_url,
     u.name as user_name
@@ -29,8 +29,9 @@ join feeds f on ff.feed_id = f.id
 `
 
 type CreateFeedFollowParams struct {
-	UserID uuid.UUID
-	FeedID uuid.UUID
+	UserID   uuid.UUID
+	FeedID   uuid.UUID
+	FolderID uuid.NullUUID
 }
 
 type CreateFeedFollowRow struct {
@@ -39,13 +40,14 @@ type CreateFeedFollowRow struct {
 	FeedID    uuid.UUID
 	CreatedAt sql.NullTime
 	UpdatedAt sql.NullTime
+	FolderID  uuid.NullUUID
 	FeedName  string
 	FeedUrl   string
 	UserName  string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
-	row := q.db.QueryRowContext(ctx, createFeedFollow, arg.UserID, arg.FeedID)
+	row := q.db.QueryRowContext(ctx, createFeedFollow, arg.UserID, arg.FeedID, arg.FolderID)
 	var i CreateFeedFollowRow
 	err := row.Scan(
 		&i.ID,
@@ -53,6 +55,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.FeedID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FolderID,
 		&i.FeedName,
 		&i.FeedUrl,
 		&i.UserName,
@@ -64,10 +67,10 @@ const deleteFeedFollow = `-- name: DeleteFeedFollow :one
 WITH ff as (
     DELETE FROM feed_follows
     WHERE feed_follows.user_id = $1 and feed_follows.feed_id = $2
-    RETURNING id, user_id, feed_id, created_at, updated_at
+    RETURNING id, user_id, feed_id, created_at, updated_at, folder_id
 )
 SELECT
-    ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
+    ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at, ff.folder_id,
     f.name as feed_name,
     f.url as feed_url,
     u.name as user_name
@@ -87,6 +90,7 @@ type DeleteFeedFollowRow struct {
 	FeedID    uuid.UUID
 	CreatedAt sql.NullTime
 	UpdatedAt sql.NullTime
+	FolderID  uuid.NullUUID
 	FeedName  string
 	FeedUrl   string
 	UserName  string
@@ -101,6 +105,7 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 		&i.FeedID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FolderID,
 		&i.FeedName,
 		&i.FeedUrl,
 		&i.UserName,
@@ -110,29 +115,48 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 select
-    ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at,
+    ff.id, ff.user_id, ff.feed_id, ff.created_at, ff.updated_at, ff.folder_id,
     f.name as feed_name,
     f.url as feed_url,
+    f.site_url as feed_site_url,
+    fo.name as folder_name,
     u.name as user_name
 from feed_follows ff
 join users u on ff.user_id = u.id
 join feeds f on ff.feed_id = f.id
+left join folders fo on ff.folder_id = fo.id
 where ff.user_id = $1
+  -- optionally only feeds with posts carrying the tag
+  and ($2::uuid is null or exists (
+      select 1
+      from post_tags pt
+      join posts p on pt.post_id = p.id
+      where pt.tag_id = $2 and p.feed_id = ff.feed_id
+  ))
+order by fo.name nulls first, f.name
 `
 
+type GetFeedFollowsForUserParams struct {
+	UserID uuid.UUID
+	TagID  uuid.NullUUID
+}
+
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	FeedName  string
-	FeedUrl   string
-	UserName  string
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	FolderID    uuid.NullUUID
+	FeedName    string
+	FeedUrl     string
+	FeedSiteUrl sql.NullString
+	FolderName  sql.NullString
+	UserName    string
 }
 
-func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, arg GetFeedFollowsForUserParams) ([]GetFeedFollowsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, arg.UserID, arg.TagID)
 	if err != nil {
 		return nil, err
 	}
@@ -146,8 +170,11 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.FeedID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.FolderID,
 			&i.FeedName,
 			&i.FeedUrl,
+			&i.FeedSiteUrl,
+			&i.FolderName,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -162,3 +189,23 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const moveFeedFollow = `-- name: MoveFeedFollow :execrows
+UPDATE feed_follows
+SET folder_id = $3, updated_at = current_timestamp
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type MoveFeedFollowParams struct {
+	UserID   uuid.UUID
+	FeedID   uuid.UUID
+	FolderID uuid.NullUUID
+}
+
+func (q *Queries) MoveFeedFollow(ctx context.Context, arg MoveFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveFeedFollow, arg.UserID, arg.FeedID, arg.FolderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -10,22 +10,90 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimNextFeedToFetch = `-- name: ClaimNextFeedToFetch :one
+UPDATE feeds
+SET last_fetched_at = current_timestamp,
+    next_fetch_at = current_timestamp + make_interval(secs => fetch_interval),
+    updated_at = current_timestamp
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= current_timestamp)
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, fetch_interval, next_fetch_at, failure_count, last_error, last_success_at, disabled_at, title, site_url, description, image_url, canonical_url, ttl, update_period, skip_hours, skip_days, moved_to
+`
+
+// Picks the feed which is due for the longest time and marks it fetched. Rows
+// locked by concurrent claims are skipped so parallel workers never fetch the
+// same feed. The feed is provisionally rescheduled with its current interval.
+func (q *Queries) ClaimNextFeedToFetch(ctx context.Context) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimNextFeedToFetch)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.ImageUrl,
+		&i.CanonicalUrl,
+		&i.Ttl,
+		&i.UpdatePeriod,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.MovedTo,
+	)
+	return i, err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (name, url, user_id)
-VALUES ($1, $2, $3)
-RETURNING id, name, url, user_id, last_fetched_at, created_at, updated_at
+INSERT INTO feeds (name, url, canonical_url, user_id, title, site_url, description, image_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, fetch_interval, next_fetch_at, failure_count, last_error, last_success_at, disabled_at, title, site_url, description, image_url, canonical_url, ttl, update_period, skip_hours, skip_days, moved_to
 `
 
 type CreateFeedParams struct {
-	Name   string
-	Url    string
-	UserID uuid.UUID
+	Name         string
+	Url          string
+	CanonicalUrl string
+	UserID       uuid.UUID
+	Title        sql.NullString
+	SiteUrl      sql.NullString
+	Description  sql.NullString
+	ImageUrl     sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, createFeed, arg.Name, arg.Url, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createFeed,
+		arg.Name,
+		arg.Url,
+		arg.CanonicalUrl,
+		arg.UserID,
+		arg.Title,
+		arg.SiteUrl,
+		arg.Description,
+		arg.ImageUrl,
+	)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -35,18 +103,41 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.LastFetchedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.ImageUrl,
+		&i.CanonicalUrl,
+		&i.Ttl,
+		&i.UpdatePeriod,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.MovedTo,
 	)
 	return i, err
 }
 
-const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, name, url, user_id, last_fetched_at, created_at, updated_at
-FROM feeds
-WHERE url = $1
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET failure_count = 0,
+    last_error = NULL,
+    disabled_at = NULL,
+    next_fetch_at = NULL,
+    updated_at = current_timestamp
+WHERE id = $1
+RETURNING id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, fetch_interval, next_fetch_at, failure_count, last_error, last_success_at, disabled_at, title, site_url, description, image_url, canonical_url, ttl, update_period, skip_hours, skip_days, moved_to
 `
 
-func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getFeedByUrl, url)
+func (q *Queries) EnableFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -56,16 +147,36 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.LastFetchedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.ImageUrl,
+		&i.CanonicalUrl,
+		&i.Ttl,
+		&i.UpdatePeriod,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.MovedTo,
 	)
 	return i, err
 }
 
-const getFeeds = `-- name: GetFeeds :many
-SELECT feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, users.name as user_name
+const getBrokenFeeds = `-- name: GetBrokenFeeds :many
+SELECT feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.fetch_interval, feeds.next_fetch_at, feeds.failure_count, feeds.last_error, feeds.last_success_at, feeds.disabled_at, feeds.title, feeds.site_url, feeds.description, feeds.image_url, feeds.canonical_url, feeds.ttl, feeds.update_period, feeds.skip_hours, feeds.skip_days, feeds.moved_to, users.name as user_name
 FROM feeds join users on feeds.user_id = users.id
+WHERE feeds.failure_count > 0 OR feeds.disabled_at IS NOT NULL
+ORDER BY feeds.disabled_at ASC NULLS LAST, feeds.failure_count DESC
 `
 
-type GetFeedsRow struct {
+type GetBrokenFeedsRow struct {
 	ID            uuid.UUID
 	Name          string
 	Url           string
@@ -73,18 +184,36 @@ type GetFeedsRow struct {
 	LastFetchedAt sql.NullTime
 	CreatedAt     sql.NullTime
 	UpdatedAt     sql.NullTime
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	FetchInterval int32
+	NextFetchAt   sql.NullTime
+	FailureCount  int32
+	LastError     sql.NullString
+	LastSuccessAt sql.NullTime
+	DisabledAt    sql.NullTime
+	Title         sql.NullString
+	SiteUrl       sql.NullString
+	Description   sql.NullString
+	ImageUrl      sql.NullString
+	CanonicalUrl  string
+	Ttl           int32
+	UpdatePeriod  int32
+	SkipHours     []int32
+	SkipDays      []int32
+	MovedTo       sql.NullString
 	UserName      string
 }
 
-func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFeeds)
+func (q *Queries) GetBrokenFeeds(ctx context.Context) ([]GetBrokenFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBrokenFeeds)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetFeedsRow
+	var items []GetBrokenFeedsRow
 	for rows.Next() {
-		var i GetFeedsRow
+		var i GetBrokenFeedsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
@@ -93,6 +222,24 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 			&i.LastFetchedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.NextFetchAt,
+			&i.FailureCount,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.DisabledAt,
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.ImageUrl,
+			&i.CanonicalUrl,
+			&i.Ttl,
+			&i.UpdatePeriod,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.MovedTo,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -108,15 +255,23 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, name, url, user_id, last_fetched_at, created_at, updated_at
+const getFeedByUrl = `-- name: GetFeedByUrl :one
+SELECT id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, fetch_interval, next_fetch_at, failure_count, last_error, last_success_at, disabled_at, title, site_url, description, image_url, canonical_url, ttl, update_period, skip_hours, skip_days, moved_to
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+WHERE canonical_url = $1 OR url = $2
+ORDER BY canonical_url = $1 DESC
 LIMIT 1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+type GetFeedByUrlParams struct {
+	CanonicalUrl string
+	Url          string
+}
+
+// Looks up a feed by the canonical form of its url. The url as stored is matched
+// as well for feeds whose canonical url could not be derived exactly on migration.
+func (q *Queries) GetFeedByUrl(ctx context.Context, arg GetFeedByUrlParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByUrl, arg.CanonicalUrl, arg.Url)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -126,17 +281,269 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.LastFetchedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.ImageUrl,
+		&i.CanonicalUrl,
+		&i.Ttl,
+		&i.UpdatePeriod,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.MovedTo,
 	)
 	return i, err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
+const getFeeds = `-- name: GetFeeds :many
+SELECT feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.fetch_interval, feeds.next_fetch_at, feeds.failure_count, feeds.last_error, feeds.last_success_at, feeds.disabled_at, feeds.title, feeds.site_url, feeds.description, feeds.image_url, feeds.canonical_url, feeds.ttl, feeds.update_period, feeds.skip_hours, feeds.skip_days, feeds.moved_to, users.name as user_name
+FROM feeds join users on feeds.user_id = users.id
+`
+
+type GetFeedsRow struct {
+	ID            uuid.UUID
+	Name          string
+	Url           string
+	UserID        uuid.UUID
+	LastFetchedAt sql.NullTime
+	CreatedAt     sql.NullTime
+	UpdatedAt     sql.NullTime
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	FetchInterval int32
+	NextFetchAt   sql.NullTime
+	FailureCount  int32
+	LastError     sql.NullString
+	LastSuccessAt sql.NullTime
+	DisabledAt    sql.NullTime
+	Title         sql.NullString
+	SiteUrl       sql.NullString
+	Description   sql.NullString
+	ImageUrl      sql.NullString
+	CanonicalUrl  string
+	Ttl           int32
+	UpdatePeriod  int32
+	SkipHours     []int32
+	SkipDays      []int32
+	MovedTo       sql.NullString
+	UserName      string
+}
+
+func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedsRow
+	for rows.Next() {
+		var i GetFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.NextFetchAt,
+			&i.FailureCount,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.DisabledAt,
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.ImageUrl,
+			&i.CanonicalUrl,
+			&i.Ttl,
+			&i.UpdatePeriod,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.MovedTo,
+			&i.UserName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET failure_count = failure_count + 1,
+    last_error = $1,
+    next_fetch_at = current_timestamp + make_interval(secs => $2::float8),
+    disabled_at = CASE WHEN $3::boolean THEN current_timestamp END,
+    updated_at = current_timestamp
+WHERE id = $4
+`
+
+type RecordFeedFailureParams struct {
+	LastError    sql.NullString
+	DelaySeconds float64
+	Disable      bool
+	ID           uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.LastError,
+		arg.DelaySeconds,
+		arg.Disable,
+		arg.ID,
+	)
+	return err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET fetch_interval = $1,
+    next_fetch_at = current_timestamp + make_interval(secs => $2::float8),
+    failure_count = 0,
+    last_error = NULL,
+    last_success_at = current_timestamp,
+    updated_at = current_timestamp
+WHERE id = $3
+`
+
+type RecordFeedSuccessParams struct {
+	FetchInterval int32
+	DelaySeconds  float64
+	ID            uuid.UUID
+}
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, arg.FetchInterval, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const releaseFeedClaim = `-- name: ReleaseFeedClaim :exec
+UPDATE feeds
+SET next_fetch_at = current_timestamp, updated_at = current_timestamp
+WHERE id = $1
+`
+
+// Makes a claimed feed due again after its fetch was aborted.
+func (q *Queries) ReleaseFeedClaim(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedClaim, id)
+	return err
+}
+
+const updateFeedCacheValidators = `-- name: UpdateFeedCacheValidators :exec
 UPDATE feeds
-SET last_fetched_at = current_timestamp, updated_at = current_timestamp
+SET etag = $2, last_modified = $3, updated_at = current_timestamp
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type UpdateFeedCacheValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheValidators(ctx context.Context, arg UpdateFeedCacheValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2, site_url = $3, description = $4, image_url = $5, updated_at = current_timestamp
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	SiteUrl     sql.NullString
+	Description sql.NullString
+	ImageUrl    sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.SiteUrl,
+		arg.Description,
+		arg.ImageUrl,
+	)
+	return err
+}
+
+const updateFeedMovedTo = `-- name: UpdateFeedMovedTo :exec
+UPDATE feeds
+SET moved_to = $2, updated_at = current_timestamp
+WHERE id = $1
+`
+
+type UpdateFeedMovedToParams struct {
+	ID      uuid.UUID
+	MovedTo sql.NullString
+}
+
+func (q *Queries) UpdateFeedMovedTo(ctx context.Context, arg UpdateFeedMovedToParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMovedTo, arg.ID, arg.MovedTo)
+	return err
+}
+
+const updateFeedScheduleHints = `-- name: UpdateFeedScheduleHints :exec
+UPDATE feeds
+SET ttl = $2, update_period = $3, skip_hours = $4, skip_days = $5, updated_at = current_timestamp
+WHERE id = $1
+`
+
+type UpdateFeedScheduleHintsParams struct {
+	ID           uuid.UUID
+	Ttl          int32
+	UpdatePeriod int32
+	SkipHours    []int32
+	SkipDays     []int32
+}
+
+func (q *Queries) UpdateFeedScheduleHints(ctx context.Context, arg UpdateFeedScheduleHintsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedScheduleHints,
+		arg.ID,
+		arg.Ttl,
+		arg.UpdatePeriod,
+		pq.Array(arg.SkipHours),
+		pq.Array(arg.SkipDays),
+	)
+	return err
+}
+
+const updateFeedUrl = `-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET url = $2, canonical_url = $3, moved_to = NULL, updated_at = current_timestamp
+WHERE id = $1
+`
+
+type UpdateFeedUrlParams struct {
+	ID           uuid.UUID
+	Url          string
+	CanonicalUrl string
+}
+
+func (q *Queries) UpdateFeedUrl(ctx context.Context, arg UpdateFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedUrl, arg.ID, arg.Url, arg.CanonicalUrl)
 	return err
 }
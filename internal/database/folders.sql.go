@@ -0,0 +1,179 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: folders.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createFolder = `-- name: CreateFolder :one
+INSERT INTO folders (user_id, name)
+VALUES ($1, $2)
+RETURNING id, user_id, name, created_at, updated_at
+`
+
+type CreateFolderParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, createFolder, arg.UserID, arg.Name)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteFolder = `-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE user_id = $1 AND name = $2
+`
+
+type DeleteFolderParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) DeleteFolder(ctx context.Context, arg DeleteFolderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFolder, arg.UserID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFolderByName = `-- name: GetFolderByName :one
+SELECT id, user_id, name, created_at, updated_at
+FROM folders
+WHERE user_id = $1 AND name = $2
+`
+
+type GetFolderByNameParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) GetFolderByName(ctx context.Context, arg GetFolderByNameParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, getFolderByName, arg.UserID, arg.Name)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getFoldersForUser = `-- name: GetFoldersForUser :many
+SELECT fo.id, fo.user_id, fo.name, fo.created_at, fo.updated_at, count(ff.id) AS feed_count
+FROM folders fo
+LEFT JOIN feed_follows ff ON ff.folder_id = fo.id
+WHERE fo.user_id = $1
+GROUP BY fo.id
+ORDER BY fo.name
+`
+
+type GetFoldersForUserRow struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+	FeedCount int64
+}
+
+func (q *Queries) GetFoldersForUser(ctx context.Context, userID uuid.UUID) ([]GetFoldersForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFoldersForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFoldersForUserRow
+	for rows.Next() {
+		var i GetFoldersForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FeedCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrCreateFolder = `-- name: GetOrCreateFolder :one
+INSERT INTO folders (user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) DO UPDATE SET name = excluded.name
+RETURNING id, user_id, name, created_at, updated_at
+`
+
+type GetOrCreateFolderParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+// The no-op update makes the existing folder part of the result.
+func (q *Queries) GetOrCreateFolder(ctx context.Context, arg GetOrCreateFolderParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, getOrCreateFolder, arg.UserID, arg.Name)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const renameFolder = `-- name: RenameFolder :one
+UPDATE folders
+SET name = $1, updated_at = current_timestamp
+WHERE user_id = $2 AND name = $3
+RETURNING id, user_id, name, created_at, updated_at
+`
+
+type RenameFolderParams struct {
+	NewName string
+	UserID  uuid.UUID
+	Name    string
+}
+
+func (q *Queries) RenameFolder(ctx context.Context, arg RenameFolderParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, renameFolder, arg.NewName, arg.UserID, arg.Name)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
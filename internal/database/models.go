@@ -6,10 +6,22 @@ package database
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+type Enclosure struct {
+	ID        uuid.UUID
+	PostID    uuid.UUID
+	Url       string
+	MimeType  sql.NullString
+	Length    sql.NullInt64
+	Duration  sql.NullInt32
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
 type Feed struct {
 	ID            uuid.UUID
 	Name          string
@@ -18,6 +30,38 @@ type Feed struct {
 	LastFetchedAt sql.NullTime
 	CreatedAt     sql.NullTime
 	UpdatedAt     sql.NullTime
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	FetchInterval int32
+	NextFetchAt   sql.NullTime
+	FailureCount  int32
+	LastError     sql.NullString
+	LastSuccessAt sql.NullTime
+	DisabledAt    sql.NullTime
+	Title         sql.NullString
+	SiteUrl       sql.NullString
+	Description   sql.NullString
+	ImageUrl      sql.NullString
+	CanonicalUrl  string
+	Ttl           int32
+	UpdatePeriod  int32
+	SkipHours     []int32
+	SkipDays      []int32
+	MovedTo       sql.NullString
+}
+
+type FeedFetch struct {
+	ID            uuid.UUID
+	FeedID        uuid.UUID
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	StatusCode    sql.NullInt32
+	Bytes         sql.NullInt64
+	ItemsSeen     int32
+	PostsInserted int32
+	Duplicates    int32
+	Error         sql.NullString
+	PostsUpdated  int32
 }
 
 type FeedFollow struct {
@@ -26,17 +70,56 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 	CreatedAt sql.NullTime
 	UpdatedAt sql.NullTime
+	FolderID  uuid.NullUUID
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
 }
 
 type Post struct {
-	ID          uuid.UUID
-	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
+	ID             uuid.UUID
+	FeedID         uuid.UUID
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Guid           sql.NullString
+	ContentHash    sql.NullString
+	Content        sql.NullString
+	RawDescription sql.NullString
+	RawContent     sql.NullString
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostTag struct {
+	TagID     uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type SavedPost struct {
+	UserID  uuid.UUID
+	PostID  uuid.UUID
+	SavedAt time.Time
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt sql.NullTime
 }
 
 type User struct {
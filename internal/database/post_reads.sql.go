@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: post_reads.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const markFollowedPostsRead = `-- name: MarkFollowedPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id)
+SELECT ff.user_id, p.id
+FROM posts p
+JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE ff.user_id = $1
+  AND ($2::uuid IS NULL OR p.feed_id = $2)
+  AND ($3::float8 IS NULL
+       OR COALESCE(p.published_at, p.created_at) < current_timestamp - make_interval(secs => $3))
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkFollowedPostsReadParams struct {
+	UserID           uuid.UUID
+	FeedID           uuid.NullUUID
+	OlderThanSeconds sql.NullFloat64
+}
+
+// Marks the posts of all followed feeds or of a single feed read, optionally
+// only those older than the given number of seconds.
+func (q *Queries) MarkFollowedPostsRead(ctx context.Context, arg MarkFollowedPostsReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markFollowedPostsRead, arg.UserID, arg.FeedID, arg.OlderThanSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markPostsRead = `-- name: MarkPostsRead :exec
+INSERT INTO post_reads (user_id, post_id)
+SELECT $1, unnest($2::uuid[])
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostsReadParams struct {
+	UserID  uuid.UUID
+	PostIds []uuid.UUID
+}
+
+func (q *Queries) MarkPostsRead(ctx context.Context, arg MarkPostsReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostsRead, arg.UserID, pq.Array(arg.PostIds))
+	return err
+}
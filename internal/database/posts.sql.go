@@ -10,76 +10,146 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (feed_id, title, url, description, published_at)
-VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, feed_id, title, url, description, published_at, created_at, updated_at
+const adoptPostGuid = `-- name: AdoptPostGuid :exec
+UPDATE posts
+SET guid = $1::text,
+    content_hash = $2::text
+WHERE id = (
+    SELECT p.id
+    FROM posts p
+    WHERE p.feed_id = $3 AND p.guid IS NULL AND p.url = ANY($4::text[])
+    LIMIT 1
+)
+AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.feed_id = $3 AND p.guid = $1)
 `
 
-type CreatePostParams struct {
+type AdoptPostGuidParams struct {
+	Guid        string
+	ContentHash string
 	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
+	Urls        []string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
+// Posts stored before guids were tracked have none. They are matched by url when
+// their feed lists them again and take over the guid of the item. The content
+// hash is backfilled as well, so adopting a post does not count as an edit.
+func (q *Queries) AdoptPostGuid(ctx context.Context, arg AdoptPostGuidParams) error {
+	_, err := q.db.ExecContext(ctx, adoptPostGuid,
+		arg.Guid,
+		arg.ContentHash,
 		arg.FeedID,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
+		pq.Array(arg.Urls),
 	)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.FeedID,
-		&i.Title,
-		&i.Url,
-		&i.Description,
-		&i.PublishedAt,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+	return err
+}
+
+const getPostsByIdPrefix = `-- name: GetPostsByIdPrefix :many
+SELECT id, feed_id, title, url, description, published_at, created_at, updated_at, guid, content_hash, content, raw_description, raw_content
+FROM posts
+WHERE left(id::text, length($1::text)) = $1::text
+LIMIT 2
+`
+
+// The prefix is compared literally, LIKE would treat % and _ as wildcards.
+func (q *Queries) GetPostsByIdPrefix(ctx context.Context, prefix string) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByIdPrefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Guid,
+			&i.ContentHash,
+			&i.Content,
+			&i.RawDescription,
+			&i.RawContent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getPostsByUser = `-- name: GetPostsByUser :many
-SELECT p.id, p.feed_id, p.title, p.url, p.description, p.published_at, p.created_at, p.updated_at, f.name, f.url
+SELECT p.id, p.feed_id, p.title, p.url, p.description, p.published_at, p.created_at, p.updated_at, p.guid, p.content_hash, p.content, p.raw_description, p.raw_content, f.name, f.url,
+       EXISTS (SELECT 1 FROM saved_posts sp WHERE sp.user_id = ff.user_id AND sp.post_id = p.id) AS saved
 FROM posts p
 JOIN feeds f ON p.feed_id = f.id
 JOIN feed_follows ff on ff.feed_id = f.id
 WHERE ff.user_id = $1
-ORDER BY p.published_at desc
-LIMIT $2
-OFFSET $3
+  AND ($2::uuid IS NULL OR ff.folder_id = $2)
+  AND ($3::uuid IS NULL
+       OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.tag_id = $3 AND pt.post_id = p.id))
+  AND (NOT $4::boolean
+       OR p.id = ANY($5::uuid[])
+       OR NOT EXISTS (SELECT 1 FROM post_reads pr WHERE pr.user_id = ff.user_id AND pr.post_id = p.id))
+ORDER BY COALESCE(p.published_at, p.created_at) desc
+LIMIT $7
+OFFSET $6
 `
 
 type GetPostsByUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
-	Offset int32
+	UserID       uuid.UUID
+	FolderID     uuid.NullUUID
+	TagID        uuid.NullUUID
+	UnreadOnly   bool
+	SessionReads []uuid.UUID
+	Offset       int32
+	Limit        int32
 }
 
 type GetPostsByUserRow struct {
-	ID          uuid.UUID
-	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
-	Name        string
-	Url_2       string
+	ID             uuid.UUID
+	FeedID         uuid.UUID
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Guid           sql.NullString
+	ContentHash    sql.NullString
+	Content        sql.NullString
+	RawDescription sql.NullString
+	RawContent     sql.NullString
+	Name           string
+	Url_2          string
+	Saved          bool
 }
 
+// With unread_only set posts read by the user are skipped - except for the
+// session_reads, the posts marked read while browsing, to keep pages stable.
 func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]GetPostsByUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsByUser, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getPostsByUser,
+		arg.UserID,
+		arg.FolderID,
+		arg.TagID,
+		arg.UnreadOnly,
+		pq.Array(arg.SessionReads),
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -96,8 +166,14 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 			&i.PublishedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Guid,
+			&i.ContentHash,
+			&i.Content,
+			&i.RawDescription,
+			&i.RawContent,
 			&i.Name,
 			&i.Url_2,
+			&i.Saved,
 		); err != nil {
 			return nil, err
 		}
@@ -111,3 +187,85 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 	}
 	return items, nil
 }
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (feed_id, guid, title, url, description, content, raw_description, raw_content, published_at, content_hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    content = excluded.content,
+    raw_description = excluded.raw_description,
+    raw_content = excluded.raw_content,
+    published_at = COALESCE(excluded.published_at, posts.published_at),
+    content_hash = excluded.content_hash,
+    updated_at = current_timestamp
+WHERE posts.content_hash IS DISTINCT FROM excluded.content_hash
+RETURNING id, feed_id, title, url, description, published_at, created_at, updated_at, guid, content_hash, content, raw_description, raw_content, (xmax = 0)::boolean AS inserted
+`
+
+type UpsertPostParams struct {
+	FeedID         uuid.UUID
+	Guid           sql.NullString
+	Title          string
+	Url            string
+	Description    sql.NullString
+	Content        sql.NullString
+	RawDescription sql.NullString
+	RawContent     sql.NullString
+	PublishedAt    sql.NullTime
+	ContentHash    sql.NullString
+}
+
+type UpsertPostRow struct {
+	ID             uuid.UUID
+	FeedID         uuid.UUID
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Guid           sql.NullString
+	ContentHash    sql.NullString
+	Content        sql.NullString
+	RawDescription sql.NullString
+	RawContent     sql.NullString
+	Inserted       bool
+}
+
+// Inserts a new post or updates the known post with the same guid if its content
+// changed. No row is returned if the post is known and unchanged.
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.FeedID,
+		arg.Guid,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.Content,
+		arg.RawDescription,
+		arg.RawContent,
+		arg.PublishedAt,
+		arg.ContentHash,
+	)
+	var i UpsertPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Guid,
+		&i.ContentHash,
+		&i.Content,
+		&i.RawDescription,
+		&i.RawContent,
+		&i.Inserted,
+	)
+	return i, err
+}
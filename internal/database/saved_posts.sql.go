@@ -0,0 +1,122 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: saved_posts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const getSavedPosts = `-- name: GetSavedPosts :many
+SELECT p.id, p.feed_id, p.title, p.url, p.description, p.published_at, p.created_at, p.updated_at, p.guid, p.content_hash, p.content, p.raw_description, p.raw_content, f.name AS feed_name, sp.saved_at
+FROM saved_posts sp
+JOIN posts p ON sp.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+WHERE sp.user_id = $1
+ORDER BY sp.saved_at DESC
+LIMIT $2
+OFFSET $3
+`
+
+type GetSavedPostsParams struct {
+	UserID uuid.UUID
+	Limit  int32
+	Offset int32
+}
+
+type GetSavedPostsRow struct {
+	ID             uuid.UUID
+	FeedID         uuid.UUID
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Guid           sql.NullString
+	ContentHash    sql.NullString
+	Content        sql.NullString
+	RawDescription sql.NullString
+	RawContent     sql.NullString
+	FeedName       string
+	SavedAt        time.Time
+}
+
+func (q *Queries) GetSavedPosts(ctx context.Context, arg GetSavedPostsParams) ([]GetSavedPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSavedPosts, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSavedPostsRow
+	for rows.Next() {
+		var i GetSavedPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Guid,
+			&i.ContentHash,
+			&i.Content,
+			&i.RawDescription,
+			&i.RawContent,
+			&i.FeedName,
+			&i.SavedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const savePost = `-- name: SavePost :exec
+INSERT INTO saved_posts (user_id, post_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type SavePostParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) SavePost(ctx context.Context, arg SavePostParams) error {
+	_, err := q.db.ExecContext(ctx, savePost, arg.UserID, arg.PostID)
+	return err
+}
+
+const unsavePost = `-- name: UnsavePost :execrows
+DELETE FROM saved_posts
+WHERE user_id = $1 AND post_id = $2
+`
+
+type UnsavePostParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) UnsavePost(ctx context.Context, arg UnsavePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unsavePost, arg.UserID, arg.PostID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,120 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: search.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const searchPosts = `-- name: SearchPosts :many
+WITH search AS (
+    SELECT websearch_to_tsquery('english', $8::text) AS query
+)
+SELECT p.id,
+       p.title,
+       p.url,
+       p.published_at,
+       p.created_at,
+       f.name AS feed_name,
+       ts_rank(
+           setweight(to_tsvector('english', coalesce(p.title, '')), 'A') ||
+           setweight(to_tsvector('english', coalesce(p.description, '')), 'B') ||
+           setweight(to_tsvector('english', coalesce(p.content, '')), 'C'),
+           search.query
+       )::float8 AS rank,
+       ts_headline(
+           'english',
+           regexp_replace(coalesce(p.content, p.description, ''), '<[^>]*>', ' ', 'g'),
+           search.query,
+           'StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=25, MinWords=10'
+       )::text AS snippet
+FROM posts p
+JOIN feeds f ON p.feed_id = f.id
+CROSS JOIN search
+WHERE (
+    setweight(to_tsvector('english', coalesce(p.title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(p.description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(p.content, '')), 'C')
+) @@ search.query
+  AND ($1::uuid IS NULL OR p.feed_id = $1)
+  AND ($2::uuid IS NULL
+       OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.tag_id = $2 AND pt.post_id = p.id))
+  AND ($3::date IS NULL OR COALESCE(p.published_at, p.created_at) >= $3::date)
+  AND ($4::date IS NULL OR COALESCE(p.published_at, p.created_at) < $4::date + 1)
+  AND (NOT $5::boolean OR EXISTS (
+      SELECT 1 FROM feed_follows ff WHERE ff.feed_id = p.feed_id AND ff.user_id = $6
+  ))
+ORDER BY rank DESC, COALESCE(p.published_at, p.created_at) DESC
+LIMIT $7
+`
+
+type SearchPostsParams struct {
+	FeedID       uuid.NullUUID
+	TagID        uuid.NullUUID
+	Since        sql.NullTime
+	Until        sql.NullTime
+	FollowedOnly bool
+	UserID       uuid.UUID
+	Limit        int32
+	Query        string
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	CreatedAt   sql.NullTime
+	FeedName    string
+	Rank        float64
+	Snippet     string
+}
+
+// Ranks the posts matching the query and highlights the matches in a snippet of
+// the post's text. The tsvector expression matches the one of posts_search_idx.
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.FeedID,
+		arg.TagID,
+		arg.Since,
+		arg.Until,
+		arg.FollowedOnly,
+		arg.UserID,
+		arg.Limit,
+		arg.Query,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,253 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: tags.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getOrCreateTag = `-- name: GetOrCreateTag :one
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) DO UPDATE SET name = excluded.name
+RETURNING id, user_id, name, created_at
+`
+
+type GetOrCreateTagParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+// The no-op update makes the existing tag part of the result.
+func (q *Queries) GetOrCreateTag(ctx context.Context, arg GetOrCreateTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getOrCreateTag, arg.UserID, arg.Name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPostsByTag = `-- name: GetPostsByTag :many
+SELECT p.id, p.feed_id, p.title, p.url, p.description, p.published_at, p.created_at, p.updated_at, p.guid, p.content_hash, p.content, p.raw_description, p.raw_content, f.name AS feed_name
+FROM post_tags pt
+JOIN posts p ON pt.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+WHERE pt.tag_id = $1
+ORDER BY pt.created_at DESC
+LIMIT $2
+`
+
+type GetPostsByTagParams struct {
+	TagID uuid.UUID
+	Limit int32
+}
+
+type GetPostsByTagRow struct {
+	ID             uuid.UUID
+	FeedID         uuid.UUID
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Guid           sql.NullString
+	ContentHash    sql.NullString
+	Content        sql.NullString
+	RawDescription sql.NullString
+	RawContent     sql.NullString
+	FeedName       string
+}
+
+func (q *Queries) GetPostsByTag(ctx context.Context, arg GetPostsByTagParams) ([]GetPostsByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByTag, arg.TagID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsByTagRow
+	for rows.Next() {
+		var i GetPostsByTagRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Guid,
+			&i.ContentHash,
+			&i.Content,
+			&i.RawDescription,
+			&i.RawContent,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagByName = `-- name: GetTagByName :one
+SELECT id, user_id, name, created_at
+FROM tags
+WHERE user_id = $1 AND name = $2
+`
+
+type GetTagByNameParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) GetTagByName(ctx context.Context, arg GetTagByNameParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTagByName, arg.UserID, arg.Name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTagsByPosts = `-- name: GetTagsByPosts :many
+SELECT pt.post_id, t.name
+FROM post_tags pt
+JOIN tags t ON pt.tag_id = t.id
+WHERE t.user_id = $1 AND pt.post_id = ANY($2::uuid[])
+ORDER BY t.name
+`
+
+type GetTagsByPostsParams struct {
+	UserID  uuid.UUID
+	PostIds []uuid.UUID
+}
+
+type GetTagsByPostsRow struct {
+	PostID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) GetTagsByPosts(ctx context.Context, arg GetTagsByPostsParams) ([]GetTagsByPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTagsByPosts, arg.UserID, pq.Array(arg.PostIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagsByPostsRow
+	for rows.Next() {
+		var i GetTagsByPostsRow
+		if err := rows.Scan(&i.PostID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagsForUser = `-- name: GetTagsForUser :many
+SELECT t.id, t.user_id, t.name, t.created_at, count(pt.post_id) AS post_count
+FROM tags t
+LEFT JOIN post_tags pt ON pt.tag_id = t.id
+WHERE t.user_id = $1
+GROUP BY t.id
+ORDER BY t.name
+`
+
+type GetTagsForUserRow struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt sql.NullTime
+	PostCount int64
+}
+
+func (q *Queries) GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]GetTagsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTagsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagsForUserRow
+	for rows.Next() {
+		var i GetTagsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.PostCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tagPost = `-- name: TagPost :exec
+INSERT INTO post_tags (tag_id, post_id)
+VALUES ($1, $2)
+ON CONFLICT (tag_id, post_id) DO NOTHING
+`
+
+type TagPostParams struct {
+	TagID  uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) TagPost(ctx context.Context, arg TagPostParams) error {
+	_, err := q.db.ExecContext(ctx, tagPost, arg.TagID, arg.PostID)
+	return err
+}
+
+const untagPost = `-- name: UntagPost :execrows
+DELETE FROM post_tags
+WHERE tag_id = $1 AND post_id = $2
+`
+
+type UntagPostParams struct {
+	TagID  uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) UntagPost(ctx context.Context, arg UntagPostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, untagPost, arg.TagID, arg.PostID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Result describes a finished download.
+type Result struct {
+	Path    string
+	Size    int64
+	Resumed bool
+}
+
+// File downloads url to path. The data is written to <path>.part first, which
+// is renamed once the download is complete. An existing .part file of an
+// interrupted download is resumed with a range request if the server supports it.
+func File(ctx context.Context, url, path string) (Result, error) {
+	partPath := path + ".part"
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating http request: %w", err)
+	}
+	req.Header.Add("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Add("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("error downloading %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && rangeStart(resp.Header.Get("Content-Range")) == offset:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// the returned range does not continue the part file - start from scratch
+		_ = resp.Body.Close()
+		if err := os.Remove(partPath); err != nil {
+			return Result{}, fmt.Errorf("error removing %s: %w", partPath, err)
+		}
+		return File(ctx, url, path)
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the part file already holds the complete content
+		if err := os.Rename(partPath, path); err != nil {
+			return Result{}, fmt.Errorf("error finishing download of %s: %w", url, err)
+		}
+		return Result{Path: path, Size: offset, Resumed: true}, nil
+	case resp.StatusCode == http.StatusOK:
+		// the server ignored the range request - start from scratch
+		flags |= os.O_TRUNC
+		offset = 0
+	default:
+		return Result{}, fmt.Errorf("error downloading %s: unexpected status %s", url, resp.Status)
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return Result{}, fmt.Errorf("error opening %s: %w", partPath, err)
+	}
+	written, err := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("error downloading %s - run again to resume: %w", url, err)
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		return Result{}, fmt.Errorf("error finishing download of %s: %w", url, err)
+	}
+	return Result{Path: path, Size: offset + written, Resumed: offset > 0}, nil
+}
+
+// rangeStart returns the first byte position of a Content-Range header like
+// "bytes 100-199/200" or -1 if the header is invalid.
+func rangeStart(contentRange string) int64 {
+	spec, found := strings.CutPrefix(contentRange, "bytes ")
+	if !found {
+		return -1
+	}
+	start, _, found := strings.Cut(spec, "-")
+	if !found {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// Exists reports whether a finished download exists at path.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
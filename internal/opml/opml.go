@@ -0,0 +1,140 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document is an OPML 2.0 subscription list.
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a feed (with an xmlUrl) or a folder of outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Feed is a subscription found in or written to a document. Category is the
+// path of the folders containing the feed, separated by slashes.
+type Feed struct {
+	Title    string
+	XMLURL   string
+	HTMLURL  string
+	Category string
+}
+
+// Parse reads an OPML document.
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing opml: %w", err)
+	}
+	return &doc, nil
+}
+
+// Feeds returns all feeds of the document with their folders as category.
+func (d *Document) Feeds() []Feed {
+	return collectFeeds(nil, "", d.Body.Outlines)
+}
+
+func collectFeeds(feeds []Feed, category string, outlines []Outline) []Feed {
+	for _, outline := range outlines {
+		name := strings.TrimSpace(outline.Text)
+		if name == "" {
+			name = strings.TrimSpace(outline.Title)
+		}
+		if outline.XMLURL != "" {
+			feeds = append(feeds, Feed{
+				Title:    name,
+				XMLURL:   strings.TrimSpace(outline.XMLURL),
+				HTMLURL:  strings.TrimSpace(outline.HTMLURL),
+				Category: category,
+			})
+			continue
+		}
+		folder := name
+		if category != "" && name != "" {
+			folder = category + "/" + name
+		} else if name == "" {
+			folder = category
+		}
+		feeds = collectFeeds(feeds, folder, outline.Outlines)
+	}
+	return feeds
+}
+
+// New creates a document listing the feeds. Feeds are nested in folder
+// outlines according to their category.
+func New(title string, feeds []Feed) *Document {
+	doc := &Document{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, feed := range feeds {
+		outlines := &doc.Body.Outlines
+		if feed.Category != "" {
+			for _, name := range strings.Split(feed.Category, "/") {
+				outlines = &folder(outlines, name).Outlines
+			}
+		}
+		*outlines = append(*outlines, Outline{
+			Text:    feed.Title,
+			Title:   feed.Title,
+			Type:    "rss",
+			XMLURL:  feed.XMLURL,
+			HTMLURL: feed.HTMLURL,
+		})
+	}
+	return doc
+}
+
+// folder returns the folder outline with the given name, adding it if needed.
+func folder(outlines *[]Outline, name string) *Outline {
+	for i := range *outlines {
+		if (*outlines)[i].XMLURL == "" && (*outlines)[i].Text == name {
+			return &(*outlines)[i]
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1]
+}
+
+// Write encodes the document as indented xml.
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,445 @@
+package render
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+)
+
+// Options control how html is rendered for the terminal.
+type Options struct {
+	// Width is the maximum line width. Lines are not wrapped if it is zero.
+	Width int
+	// ANSI enables bold, italic and underlined text using ANSI escape codes.
+	ANSI bool
+	// BaseURL is used to resolve relative links and images.
+	BaseURL string
+}
+
+// block is a paragraph of inline text. The first line starts with the first
+// prefix, all following lines with the rest prefix.
+type block struct {
+	first, rest string
+	text        string
+	pre         bool
+	blankBefore bool
+}
+
+type list struct {
+	ordered bool
+	next    int
+}
+
+type renderer struct {
+	opts   Options
+	base   *url.URL
+	blocks []block
+
+	inline strings.Builder
+	// visible is set once the current paragraph contains visible text,
+	// trailingSpace if that text ends with a space
+	visible       bool
+	trailingSpace bool
+
+	blankBefore bool
+	bullet      string
+	lists       []list
+	quotes      int
+	pre         int
+
+	links     []string
+	linkIndex map[string]int
+}
+
+// HTML converts the html fragment into wrapped plain text for the terminal.
+// Links and images are replaced by numbered references which are listed as
+// footnotes at the end of the text.
+func HTML(s string, opts Options) string {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return s
+	}
+	r := &renderer{opts: opts, linkIndex: make(map[string]int)}
+	if base, err := url.Parse(opts.BaseURL); err == nil && opts.BaseURL != "" {
+		r.base = base
+	}
+	for _, node := range nodes {
+		r.render(node)
+	}
+	r.flush(false)
+	return r.String()
+}
+
+func (r *renderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Noscript, atom.Template, atom.Iframe, atom.Object, atom.Svg:
+		return
+	case atom.Br:
+		r.flush(false)
+	case atom.Hr:
+		r.flush(true)
+		r.blocks = append(r.blocks, block{text: strings.Repeat("─", r.lineWidth(40)), blankBefore: true})
+		r.blankBefore = true
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Figure, atom.Figcaption,
+		atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Address, atom.Main, atom.Aside, atom.Nav:
+		r.flush(true)
+		r.children(n)
+		r.flush(true)
+	case atom.Dd:
+		r.flush(false)
+		r.quotes++
+		r.children(n)
+		r.flush(false)
+		r.quotes--
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.flush(true)
+		r.styled(n, ansiBold)
+		r.flush(true)
+	case atom.Ul, atom.Ol:
+		r.flush(len(r.lists) == 0)
+		start := 1
+		if i, err := strconv.Atoi(attr(n, "start")); err == nil {
+			start = i
+		}
+		r.lists = append(r.lists, list{ordered: n.DataAtom == atom.Ol, next: start})
+		r.children(n)
+		r.flush(false)
+		r.lists = r.lists[:len(r.lists)-1]
+		r.blankBefore = len(r.lists) == 0
+	case atom.Li:
+		r.flush(false)
+		r.bullet = "• "
+		if len(r.lists) > 0 {
+			current := &r.lists[len(r.lists)-1]
+			if current.ordered {
+				r.bullet = fmt.Sprintf("%d. ", current.next)
+				current.next++
+			}
+		}
+		r.children(n)
+		r.flush(false)
+	case atom.Blockquote:
+		r.flush(true)
+		r.quotes++
+		r.children(n)
+		r.flush(true)
+		r.quotes--
+	case atom.Pre:
+		r.flush(true)
+		r.pre++
+		r.children(n)
+		r.pre--
+		r.flushPre()
+	case atom.Td, atom.Th:
+		r.space()
+		r.children(n)
+		r.space()
+	case atom.B, atom.Strong:
+		r.styled(n, ansiBold)
+	case atom.I, atom.Em, atom.Cite:
+		r.styled(n, ansiItalic)
+	case atom.U:
+		r.styled(n, ansiUnderline)
+	case atom.A:
+		r.styled(n, ansiUnderline)
+		href := attr(n, "href")
+		if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "javascript:") {
+			r.write(r.reference(href))
+		}
+	case atom.Img:
+		r.image(n)
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+func (r *renderer) styled(n *html.Node, code string) {
+	if !r.opts.ANSI {
+		r.children(n)
+		return
+	}
+	r.inline.WriteString(code)
+	r.children(n)
+	r.inline.WriteString(ansiReset)
+	// the reset ends all styles - restore the ones of enclosing elements
+	for p := n.Parent; p != nil; p = p.Parent {
+		if parentCode := ansiCode(p); parentCode != "" {
+			r.inline.WriteString(parentCode)
+		}
+	}
+}
+
+func (r *renderer) image(n *html.Node) {
+	// tracking pixels carry no information
+	if attr(n, "width") == "1" || attr(n, "height") == "1" || attr(n, "width") == "0" || attr(n, "height") == "0" {
+		return
+	}
+	alt := strings.TrimSpace(attr(n, "alt"))
+	label := "[image]"
+	if alt != "" {
+		label = "[image: " + alt + "]"
+	}
+	r.space()
+	r.write(label)
+	if src := attr(n, "src"); src != "" && !strings.HasPrefix(src, "data:") {
+		r.write(r.reference(src))
+	}
+	r.space()
+}
+
+// reference returns the footnote marker for the given url. Each url gets one
+// footnote no matter how often it is referenced.
+func (r *renderer) reference(href string) string {
+	if r.base != nil {
+		if u, err := r.base.Parse(href); err == nil {
+			href = u.String()
+		}
+	}
+	i, ok := r.linkIndex[href]
+	if !ok {
+		r.links = append(r.links, href)
+		i = len(r.links)
+		r.linkIndex[href] = i
+	}
+	return fmt.Sprintf("[%d]", i)
+}
+
+func (r *renderer) text(s string) {
+	s = stripControl(s)
+	if r.pre > 0 {
+		r.write(s)
+		return
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		r.space()
+		return
+	}
+	if startsWithSpace(s) {
+		r.space()
+	}
+	r.write(strings.Join(fields, " "))
+	if endsWithSpace(s) {
+		r.space()
+	}
+}
+
+func (r *renderer) write(s string) {
+	if s == "" {
+		return
+	}
+	r.inline.WriteString(s)
+	r.visible = true
+	r.trailingSpace = strings.HasSuffix(s, " ")
+}
+
+// space adds a single separating space unless the paragraph is empty or already ends with a space.
+func (r *renderer) space() {
+	if !r.visible || r.trailingSpace {
+		return
+	}
+	r.write(" ")
+}
+
+// flush ends the current paragraph. Paragraphs started after a flush with
+// blank set are separated by an empty line.
+func (r *renderer) flush(blank bool) {
+	if r.visible && strings.TrimSpace(stripANSI(r.inline.String())) != "" {
+		first, rest := r.prefixes()
+		r.blocks = append(r.blocks, block{first: first, rest: rest, text: r.inline.String(), blankBefore: r.blankBefore})
+		r.bullet = ""
+		r.blankBefore = false
+	}
+	r.resetInline()
+	if blank {
+		r.blankBefore = true
+	}
+}
+
+func (r *renderer) flushPre() {
+	text := strings.Trim(r.inline.String(), "\n")
+	if text != "" {
+		first, rest := r.prefixes()
+		r.blocks = append(r.blocks, block{first: first, rest: rest, text: text, pre: true, blankBefore: true})
+	}
+	r.resetInline()
+	r.blankBefore = true
+}
+
+func (r *renderer) resetInline() {
+	r.inline.Reset()
+	r.visible = false
+	r.trailingSpace = false
+}
+
+func (r *renderer) prefixes() (string, string) {
+	prefix := strings.Repeat("│ ", r.quotes)
+	if len(r.lists) > 1 {
+		prefix += strings.Repeat("  ", len(r.lists)-1)
+	}
+	if r.bullet == "" {
+		if len(r.lists) > 0 {
+			prefix += "  "
+		}
+		return prefix, prefix
+	}
+	return prefix + r.bullet, prefix + strings.Repeat(" ", utf8.RuneCountInString(r.bullet))
+}
+
+func (r *renderer) lineWidth(fallback int) int {
+	if r.opts.Width > 0 {
+		return r.opts.Width
+	}
+	return fallback
+}
+
+func (r *renderer) String() string {
+	var out []string
+	for _, b := range r.blocks {
+		if b.blankBefore && len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+		if b.pre {
+			for i, line := range strings.Split(b.text, "\n") {
+				prefix := b.rest
+				if i == 0 {
+					prefix = b.first
+				}
+				out = append(out, prefix+line)
+			}
+			continue
+		}
+		out = append(out, wrap(b.text, b.first, b.rest, r.opts.Width)...)
+	}
+
+	if len(r.links) > 0 {
+		out = append(out, "")
+		for i, link := range r.links {
+			out = append(out, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrap breaks the text into lines of at most width visible characters. Words
+// longer than the line are not split.
+func wrap(text, first, rest string, width int) []string {
+	words := strings.Fields(text)
+	var lines []string
+	line := first
+	lineWidth := visibleWidth(first)
+	empty := true
+	for _, word := range words {
+		w := visibleWidth(word)
+		if !empty && width > 0 && lineWidth+1+w > width {
+			lines = append(lines, line)
+			line, lineWidth, empty = rest, visibleWidth(rest), true
+		}
+		if !empty {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += w
+		empty = false
+	}
+	return append(lines, line)
+}
+
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(stripANSI(s))
+}
+
+// stripANSI removes the escape sequences added by the renderer.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "\x1b[")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		j := strings.IndexByte(s[i:], 'm')
+		if j < 0 {
+			return b.String()
+		}
+		s = s[i+j+1:]
+	}
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return strings.ContainsRune(" \t\n\r\f", r)
+}
+
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return strings.ContainsRune(" \t\n\r\f", r)
+}
+
+// ansiCode returns the escape code used to style the content of the element.
+func ansiCode(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.B, atom.Strong, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return ansiBold
+	case atom.I, atom.Em, atom.Cite:
+		return ansiItalic
+	case atom.U, atom.A:
+		return ansiUnderline
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return stripControl(a.Val)
+		}
+	}
+	return ""
+}
+
+// stripControl removes control characters except newlines and tabs. Feeds must
+// not be able to send escape sequences to the terminal.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, s)
+}
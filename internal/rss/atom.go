@@ -0,0 +1,162 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+)
+
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    AtomText    `xml:"title"`
+	Subtitle AtomText    `xml:"subtitle"`
+	Links    []AtomLink  `xml:"link"`
+	Icon     string      `xml:"icon"`
+	Logo     string      `xml:"logo"`
+	Updated  string      `xml:"updated"`
+	Entry    []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID        string     `xml:"id"`
+	Title     AtomText   `xml:"title"`
+	Links     []AtomLink `xml:"link"`
+	Summary   AtomText   `xml:"summary"`
+	Content   AtomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// AtomText is an atom text construct. Depending on its type the payload is
+// plain text, escaped html or inline xhtml markup.
+type AtomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// PlainText returns the text construct as plain text. The payload is decoded
+// exactly once according to its type.
+func (t AtomText) PlainText() string {
+	switch t.kind() {
+	case "html":
+		return html.UnescapeString(strings.TrimSpace(t.Text))
+	case "xhtml":
+		return xhtmlText(t.InnerXML)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// xhtmlText returns the character data of inline xhtml markup without its tags.
+func xhtmlText(inner string) string {
+	var b strings.Builder
+	decoder := xml.NewDecoder(strings.NewReader(inner))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if data, ok := token.(xml.CharData); ok {
+			b.Write(data)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// HTML returns the text construct as html markup. Plain text is escaped so it
+// is not mistaken for markup.
+func (t AtomText) HTML() string {
+	switch t.kind() {
+	case "html":
+		return strings.TrimSpace(t.Text)
+	case "xhtml":
+		return strings.TrimSpace(t.InnerXML)
+	}
+	return html.EscapeString(strings.TrimSpace(t.Text))
+}
+
+// kind maps the type of the construct to text, html or xhtml. Content elements
+// may use mime types as well.
+func (t AtomText) kind() string {
+	switch strings.ToLower(strings.TrimSpace(t.Type)) {
+	case "html", "text/html":
+		return "html"
+	case "xhtml", "application/xhtml+xml":
+		return "xhtml"
+	}
+	return "text"
+}
+
+// alternateLink picks the link pointing to the html representation of an atom element.
+func alternateLink(links []AtomLink) string {
+	var href string
+	for _, link := range links {
+		if link.Rel != "" && link.Rel != "alternate" {
+			continue
+		}
+		if link.Type == "" || link.Type == "text/html" {
+			return link.Href
+		}
+		if href == "" {
+			href = link.Href
+		}
+	}
+	return href
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var atom AtomFeed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("error parsing atom feed: %w", err)
+	}
+
+	feed := &Feed{
+		Format:      FormatAtom,
+		Title:       atom.Title.PlainText(),
+		Link:        alternateLink(atom.Links),
+		Description: atom.Subtitle.PlainText(),
+		Image:       firstNonEmpty(atom.Logo, atom.Icon),
+		Items:       make([]Item, 0, len(atom.Entry)),
+	}
+
+	for _, entry := range atom.Entry {
+		item := Item{
+			ID:          strings.TrimSpace(entry.ID),
+			Title:       entry.Title.PlainText(),
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary.HTML(),
+			Content:     entry.Content.HTML(),
+		}
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = appendEnclosure(item.Enclosures, Enclosure{
+					URL:    link.Href,
+					Type:   link.Type,
+					Length: parseLength(link.Length),
+				})
+			}
+		}
+
+		item.Updated = parseOptionalDate(entry.Updated)
+		item.Published = parseOptionalDate(entry.Published)
+		if item.Published.IsZero() {
+			item.Published = item.Updated
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
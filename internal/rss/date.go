@@ -0,0 +1,170 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// rfc822Layouts covers the RFC 822, 1123 and 2822 families as they are used in
+// the wild. The redundant weekday prefix is removed before matching and named
+// time zones are replaced by their numeric offset.
+var rfc822Layouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 January 2006 15:04:05",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2, 2006 15:04:05 -0700",
+	"January 2, 2006 15:04:05 -0700",
+	"January 2, 2006",
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// isoLayouts covers RFC 3339 and the W3C date time profile of ISO 8601.
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+	"2006-01",
+	"2006",
+}
+
+// zoneOffsets maps time zone names found in feeds to their numeric offset.
+// time.Parse silently assumes UTC for abbreviations unknown to the local system.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"BST":  "+0100",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"MEZ":  "+0100",
+	"MESZ": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"IST":  "+0530",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+// ParseDate parses publication dates in the formats commonly found in RSS,
+// Atom and JSON feeds. The result is normalized to UTC. Dates without any
+// time zone information are interpreted as UTC.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%w: empty value", ErrInvalidDate)
+	}
+
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	normalized := normalizeRFC822(value)
+	for _, layout := range rfc822Layouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, value)
+}
+
+// parseOptionalDate returns the zero time for missing or unparseable dates.
+func parseOptionalDate(value string) time.Time {
+	t, err := ParseDate(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// normalizeRFC822 strips a leading weekday and a trailing comment like "(CET)"
+// and replaces a trailing zone name like "GMT" or "EST" by its numeric offset.
+func normalizeRFC822(value string) string {
+	if weekday, rest, found := strings.Cut(value, ","); found && isAlpha(weekday) {
+		value = strings.TrimSpace(rest)
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return value
+	}
+	if comment := fields[len(fields)-1]; strings.HasPrefix(comment, "(") && strings.HasSuffix(comment, ")") {
+		// RFC 2822 allows a comment naming the zone after the numeric offset,
+		// without an offset the comment is the only zone information
+		if len(fields) > 1 && hasZone(fields[len(fields)-2]) {
+			fields = fields[:len(fields)-1]
+		} else {
+			fields[len(fields)-1] = strings.Trim(comment, "()")
+		}
+	}
+	last := strings.ToUpper(fields[len(fields)-1])
+	if offset, ok := zoneOffsets[last]; ok {
+		fields[len(fields)-1] = offset
+	} else if strings.HasPrefix(last, "GMT+") || strings.HasPrefix(last, "GMT-") || strings.HasPrefix(last, "UTC+") || strings.HasPrefix(last, "UTC-") {
+		fields[len(fields)-1] = last[3:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// hasZone reports whether the field is a numeric offset or a known zone name.
+func hasZone(field string) bool {
+	if strings.HasPrefix(field, "+") || strings.HasPrefix(field, "-") {
+		return true
+	}
+	_, ok := zoneOffsets[strings.ToUpper(field)]
+	return ok
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
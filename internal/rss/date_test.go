@@ -0,0 +1,59 @@
+package rss
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want time.Time
+	}{
+		{"rfc 1123", "Thu, 01 Feb 2024 10:00:00 GMT", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"rfc 1123 numeric zone", "Thu, 01 Feb 2024 10:00:00 +0100", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"rfc 2822 zone comment", "Thu, 01 Feb 2024 10:00:00 +0100 (CET)", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"named zone", "Thu, 01 Feb 2024 10:00:00 EST", time.Date(2024, 2, 1, 15, 0, 0, 0, time.UTC)},
+		{"named zone in parentheses", "Thu, 01 Feb 2024 10:00:00 (EST)", time.Date(2024, 2, 1, 15, 0, 0, 0, time.UTC)},
+		{"zone name with comment", "Thu, 01 Feb 2024 10:00:00 GMT (UTC)", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"lower case zone", "Thu, 01 Feb 2024 10:00:00 cest", time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)},
+		{"gmt offset", "Thu, 01 Feb 2024 10:00:00 GMT+0200", time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)},
+		{"no weekday", "1 Feb 2024 10:00:00 +0000", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"two digit year", "Thu, 01 Feb 24 10:00:00 +0000", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"no seconds", "Thu, 01 Feb 2024 10:00 +0000", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"long month", "1 February 2024 10:00:00 +0000", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"us style", "February 1, 2024", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"extra whitespace", "  Thu,  01 Feb 2024\t10:00:00  GMT ", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"rfc 3339", "2024-02-01T10:00:00+01:00", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"rfc 3339 utc", "2024-02-01T10:00:00Z", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"rfc 3339 fraction", "2024-02-01T10:00:00.123Z", time.Date(2024, 2, 1, 10, 0, 0, 123000000, time.UTC)},
+		{"iso without zone", "2024-02-01T10:00:00", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"iso with space", "2024-02-01 10:00:00", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"iso with space and offset", "2024-02-01 10:00:00 +0100", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"iso with space and colon offset", "2024-02-01 10:00:00 +01:00", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"date only", "2024-02-01", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"month only", "2024-02", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"ansi c", "Thu Feb  1 10:00:00 2024", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.in)
+			if err != nil {
+				t.Fatalf("ParseDate(%q) error: %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, in := range []string{"", "   ", "yesterday", "32 Feb 2024 10:00:00 GMT", "(CET)"} {
+		if _, err := ParseDate(in); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("ParseDate(%q) error = %v, want ErrInvalidDate", in, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrNoFeedFound = errors.New("no feed found")
+
+// feedTypes are the mime types announced by <link rel="alternate"> for feeds.
+var feedTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+	// older JSON Feeds are announced as plain json like many APIs, e.g. the
+	// WordPress REST API - these candidates are kept only if they are feeds
+	"application/json": true,
+}
+
+// wellKnownPaths are probed if a website does not link its feeds.
+var wellKnownPaths = []string{"/feed", "/rss.xml", "/atom.xml"}
+
+// Candidate is a feed found by Discover.
+type Candidate struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+// Discover finds the feeds of the website at pageURL. If pageURL is a feed
+// itself it is the only candidate. Otherwise the feeds announced by
+// <link rel="alternate"> elements are returned, falling back to probing well
+// known feed locations. ErrNoFeedFound is returned if there is none.
+func Discover(ctx context.Context, pageURL string) ([]Candidate, error) {
+	page, err := fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if feed, err := parseFeed(page.contentType, page.data); err == nil {
+		return []Candidate{{URL: pageURL, Title: feed.Title, Type: string(feed.Format)}}, nil
+	}
+
+	// relative urls are relative to the page the request was redirected to
+	base := page.url
+	if candidates := verifyJSONFeeds(ctx, feedLinks(page.data, base)); len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	var candidates []Candidate
+	for _, path := range wellKnownPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		candidateURL := base.ResolveReference(&url.URL{Path: path}).String()
+		page, err := fetch(ctx, candidateURL)
+		if err != nil {
+			continue
+		}
+		if feed, err := parseFeed(page.contentType, page.data); err == nil {
+			candidates = append(candidates, Candidate{URL: candidateURL, Title: feed.Title, Type: string(feed.Format)})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoFeedFound, pageURL)
+	}
+	return candidates, nil
+}
+
+// feedLinks collects the feeds announced in the html document. Relative urls
+// are resolved against base or the document's <base href>.
+func feedLinks(data []byte, base *url.URL) []Candidate {
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return candidates
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Body:
+				// feeds are announced in the head
+				return candidates
+			case atom.Base:
+				if u, err := base.Parse(attribute(token, "href")); err == nil {
+					base = u
+				}
+			case atom.Link:
+				if !hasToken(attribute(token, "rel"), "alternate") {
+					continue
+				}
+				mimeType := strings.ToLower(strings.TrimSpace(attribute(token, "type")))
+				if !feedTypes[mimeType] {
+					continue
+				}
+				u, err := base.Parse(strings.TrimSpace(attribute(token, "href")))
+				if err != nil || seen[u.String()] {
+					continue
+				}
+				seen[u.String()] = true
+				candidates = append(candidates, Candidate{URL: u.String(), Title: stripControl(attribute(token, "title")), Type: mimeType})
+			}
+		}
+	}
+}
+
+// verifyJSONFeeds drops the candidates announced as plain json which turn out
+// not to be feeds.
+func verifyJSONFeeds(ctx context.Context, candidates []Candidate) []Candidate {
+	var verified []Candidate
+	for _, candidate := range candidates {
+		if candidate.Type == "application/json" {
+			page, err := fetch(ctx, candidate.URL)
+			if err != nil {
+				continue
+			}
+			if _, err := parseFeed(page.contentType, page.data); err != nil {
+				continue
+			}
+		}
+		verified = append(verified, candidate)
+	}
+	return verified
+}
+
+type page struct {
+	url         *url.URL
+	contentType string
+	data        []byte
+}
+
+func fetch(ctx context.Context, pageURL string) (page, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return page{}, fmt.Errorf("error creating http request: %w", err)
+	}
+	req.Header.Add("User-Agent", "gator")
+	req.Header.Add("Accept", "text/html, application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return page{}, fmt.Errorf("error fetching %s: %w", pageURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return page{}, fmt.Errorf("error fetching %s: unexpected status %s", pageURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return page{}, fmt.Errorf("error reading %s: %w", pageURL, err)
+	}
+	return page{url: resp.Request.URL, contentType: resp.Header.Get("Content-Type"), data: data}, nil
+}
+
+func attribute(token html.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether the space separated list contains token.
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
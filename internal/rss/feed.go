@@ -0,0 +1,257 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// fetchTimeout limits a whole request including reading the response, so a
+// server which never answers cannot block a fetch forever.
+const fetchTimeout = 30 * time.Second
+
+// Format identifies the syndication format a feed was published in.
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+	FormatRDF  Format = "rdf"
+)
+
+// Feed is the format independent representation of a fetched feed.
+type Feed struct {
+	Format      Format
+	Title       string
+	Link        string
+	Description string
+	// Image is the url of the logo or icon of the feed.
+	Image    string
+	Items    []Item
+	Schedule Schedule
+
+	// NotModified is set when the server answered a conditional request with
+	// 304 Not Modified. The feed carries no content in this case.
+	NotModified bool
+	// Validators are the cache validators to send with the next request.
+	Validators CacheValidators
+	// StatusCode and Bytes describe the http response the feed was read from.
+	StatusCode int
+	Bytes      int64
+	// MovedTo is the url the feed was permanently redirected to (301 or 308).
+	MovedTo string
+}
+
+// CacheValidators are the ETag and Last-Modified headers of a previous
+// response used to issue conditional requests.
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// Item is the format independent representation of a single feed entry.
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	// Content is the full html content of the item if the feed provides it
+	// in addition to the description.
+	Content    string
+	Authors    []string
+	Enclosures []Enclosure
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Enclosure is a media file attached to an item.
+type Enclosure struct {
+	URL      string
+	Type     string
+	Length   int64
+	Duration time.Duration
+}
+
+var ErrUnsupportedFormat = errors.New("unsupported feed format")
+
+// FetchError is returned by FetchFeed if the server responded but the response
+// could not be turned into a feed.
+type FetchError struct {
+	StatusCode int
+	Bytes      int64
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// FetchFeed downloads and parses the feed at feedURL. Non-empty validators turn
+// the request into a conditional request; an unchanged feed is reported with
+// NotModified set instead of an error.
+func FetchFeed(ctx context.Context, feedURL string, validators CacheValidators) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating http request: %w", err)
+	}
+	// permanent redirects are reported to update the stored url - a chain of
+	// redirects is followed only as far as all of them are permanent
+	var movedTo string
+	permanent := true
+	client := &http.Client{
+		Timeout: fetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			status := req.Response.StatusCode
+			if permanent && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect) {
+				movedTo = req.URL.String()
+			} else {
+				permanent = false
+			}
+			return nil
+		},
+	}
+	req.Header.Add("User-Agent", "gator")
+	req.Header.Add("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	if validators.ETag != "" {
+		req.Header.Add("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Add("If-Modified-Since", validators.LastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rss from %s: %w", feedURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &Feed{NotModified: true, Validators: validators, StatusCode: resp.StatusCode, MovedTo: movedTo}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("error fetching rss from %s: unexpected status %s", feedURL, resp.Status),
+		}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &FetchError{
+			StatusCode: resp.StatusCode,
+			Bytes:      int64(len(data)),
+			Err:        fmt.Errorf("error reading feed from %s: %w", feedURL, err),
+		}
+	}
+	feed, err := parseFeed(resp.Header.Get("Content-Type"), data)
+	if err != nil {
+		return nil, &FetchError{StatusCode: resp.StatusCode, Bytes: int64(len(data)), Err: err}
+	}
+	feed.Validators = CacheValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	feed.StatusCode = resp.StatusCode
+	feed.Bytes = int64(len(data))
+	feed.MovedTo = movedTo
+	return feed, nil
+}
+
+// ParseFeed detects the format of the given document and decodes it into a Feed.
+func ParseFeed(data []byte) (*Feed, error) {
+	return parseFeed("", data)
+}
+
+func parseFeed(contentType string, data []byte) (*Feed, error) {
+	feed, err := decodeFeed(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	feed.stripControl()
+	return feed, nil
+}
+
+func decodeFeed(contentType string, data []byte) (*Feed, error) {
+	if isJSONFeed(contentType, data) {
+		return parseJSONFeed(data)
+	}
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing feed: %w", err)
+	}
+	switch {
+	case root.Local == "rss":
+		return parseRSS(data)
+	case root.Local == "feed" && root.Space == atomNamespace:
+		return parseAtom(data)
+	case root.Local == "RDF" && root.Space == rdfNamespace:
+		return parseRDF(data)
+	}
+	return nil, fmt.Errorf("%w: root element <%s>", ErrUnsupportedFormat, root.Local)
+}
+
+// stripControl removes control characters from the plain text fields and urls
+// of the feed, which are printed to the terminal as they are. The html of
+// descriptions and content is filtered when it is rendered.
+func (f *Feed) stripControl() {
+	f.Title = stripControl(f.Title)
+	f.Link = stripControl(f.Link)
+	f.Description = stripControl(f.Description)
+	f.Image = stripControl(f.Image)
+	for i := range f.Items {
+		item := &f.Items[i]
+		item.ID = stripControl(item.ID)
+		item.Title = stripControl(item.Title)
+		item.Link = stripControl(item.Link)
+		for j := range item.Authors {
+			item.Authors[j] = stripControl(item.Authors[j])
+		}
+		for j := range item.Enclosures {
+			item.Enclosures[j].URL = stripControl(item.Enclosures[j].URL)
+			item.Enclosures[j].Type = stripControl(item.Enclosures[j].Type)
+		}
+	}
+}
+
+// stripControl removes control characters. Line breaks and tabs are turned
+// into spaces.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' {
+			return ' '
+		}
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// rootElement returns the name of the first element of the given xml document.
+func rootElement(data []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package rss
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Icon        string         `json:"icon"`
+	Favicon     string         `json:"favicon"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []JSONFeedAuthor     `json:"authors"`
+	Author        *JSONFeedAuthor      `json:"author"` // deprecated since JSON Feed 1.1
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type JSONFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+// isJSONFeed reports whether the given content type or payload indicates a JSON Feed document.
+func isJSONFeed(contentType string, data []byte) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "application/feed+json", "application/json":
+		return true
+	}
+	trimmed := strings.TrimSpace(string(data[:min(len(data), 512)]))
+	return strings.HasPrefix(trimmed, "{")
+}
+
+func parseJSONFeed(data []byte) (*Feed, error) {
+	var jsonFeed JSONFeed
+	if err := json.Unmarshal(data, &jsonFeed); err != nil {
+		return nil, fmt.Errorf("error parsing json feed: %w", err)
+	}
+	if !strings.HasPrefix(jsonFeed.Version, jsonFeedVersionPrefix) {
+		return nil, fmt.Errorf("%w: unknown json feed version %q", ErrUnsupportedFormat, jsonFeed.Version)
+	}
+
+	feed := &Feed{
+		Format:      FormatJSON,
+		Title:       jsonFeed.Title,
+		Link:        jsonFeed.HomePageURL,
+		Description: jsonFeed.Description,
+		Image:       firstNonEmpty(jsonFeed.Icon, jsonFeed.Favicon),
+		Items:       make([]Item, 0, len(jsonFeed.Items)),
+	}
+
+	for _, jsonItem := range jsonFeed.Items {
+		item := Item{
+			ID:          jsonItem.ID,
+			Title:       jsonItem.Title,
+			Link:        jsonItem.URL,
+			Description: jsonItem.Summary,
+		}
+		if item.Link == "" && strings.HasPrefix(jsonItem.ID, "http") {
+			item.Link = jsonItem.ID
+		}
+		item.Content = jsonItem.ContentHTML
+		if item.Content == "" {
+			item.Content = html.EscapeString(jsonItem.ContentText)
+		}
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+
+		authors := jsonItem.Authors
+		if len(authors) == 0 && jsonItem.Author != nil {
+			authors = append(authors, *jsonItem.Author)
+		}
+		for _, author := range authors {
+			if author.Name != "" {
+				item.Authors = append(item.Authors, author.Name)
+			}
+		}
+
+		for _, attachment := range jsonItem.Attachments {
+			item.Enclosures = appendEnclosure(item.Enclosures, Enclosure{
+				URL:      attachment.URL,
+				Type:     attachment.MimeType,
+				Length:   attachment.SizeInBytes,
+				Duration: time.Duration(attachment.DurationInSeconds * float64(time.Second)),
+			})
+		}
+
+		item.Updated = parseOptionalDate(jsonItem.DateModified)
+		item.Published = parseOptionalDate(jsonItem.DatePublished)
+		if item.Published.IsZero() {
+			item.Published = item.Updated
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
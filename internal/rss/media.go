@@ -0,0 +1,75 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RSSEnclosure is the RSS 2.0 <enclosure> element.
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// MediaContent is the <media:content> element of Media RSS.
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+// appendEnclosure adds the enclosure unless another one with the same url is
+// already known. Feeds often list the same file as <enclosure> and as
+// <media:content> - missing details are completed from the duplicate instead.
+func appendEnclosure(enclosures []Enclosure, enclosure Enclosure) []Enclosure {
+	enclosure.URL = strings.TrimSpace(enclosure.URL)
+	if enclosure.URL == "" {
+		return enclosures
+	}
+	for i, known := range enclosures {
+		if known.URL != enclosure.URL {
+			continue
+		}
+		if known.Type == "" {
+			known.Type = enclosure.Type
+		}
+		if known.Length == 0 {
+			known.Length = enclosure.Length
+		}
+		if known.Duration == 0 {
+			known.Duration = enclosure.Duration
+		}
+		enclosures[i] = known
+		return enclosures
+	}
+	return append(enclosures, enclosure)
+}
+
+func parseLength(value string) int64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return length
+}
+
+// parseDuration parses media durations given in seconds or as [[HH:]MM:]SS
+// like in itunes:duration.
+func parseDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,73 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+)
+
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// RDFFeed is an RSS 1.0 document. In contrast to RSS 2.0 the items are
+// siblings of the channel element.
+type RDFFeed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		syndication
+	} `xml:"channel"`
+	Image struct {
+		URL string `xml:"url"`
+	} `xml:"image"`
+	Item []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+func parseRDF(data []byte) (*Feed, error) {
+	var rdf RDFFeed
+	if err := xml.Unmarshal(data, &rdf); err != nil {
+		return nil, fmt.Errorf("error parsing rdf feed: %w", err)
+	}
+
+	feed := &Feed{
+		Format:      FormatRDF,
+		Title:       html.UnescapeString(strings.TrimSpace(rdf.Channel.Title)),
+		Link:        strings.TrimSpace(rdf.Channel.Link),
+		Description: strings.TrimSpace(rdf.Channel.Description),
+		Image:       strings.TrimSpace(rdf.Image.URL),
+		Items:       make([]Item, 0, len(rdf.Item)),
+		Schedule:    Schedule{UpdatePeriod: rdf.Channel.period()},
+	}
+
+	for _, rdfItem := range rdf.Item {
+		item := Item{
+			ID:          rdfItem.About,
+			Title:       html.UnescapeString(strings.TrimSpace(rdfItem.Title)),
+			Link:        strings.TrimSpace(rdfItem.Link),
+			Description: strings.TrimSpace(rdfItem.Description),
+			Content:     strings.TrimSpace(rdfItem.Content),
+		}
+		if rdfItem.Creator != "" {
+			item.Authors = []string{strings.TrimSpace(rdfItem.Creator)}
+		}
+		if item.Link == "" {
+			item.Link = rdfItem.About
+		}
+		item.Published = parseOptionalDate(rdfItem.Date)
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
@@ -1,56 +1,113 @@
 package rss
 
 import (
-	"context"
 	"encoding/xml"
 	"fmt"
 	"html"
-	"net/http"
+	"strings"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title string `xml:"title"`
+		// an atom:link element matches as well - it has no content though
+		Links       []string `xml:"link"`
+		Description string   `xml:"description"`
+		// the itunes image has to come first, the plain image matches all namespaces
+		ITunesImage struct {
+			Href string `xml:"href,attr"`
+		} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		Image struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+		TTL       string    `xml:"ttl"`
+		SkipHours []string  `xml:"skipHours>hour"`
+		SkipDays  []string  `xml:"skipDays>day"`
+		Item      []RSSItem `xml:"item"`
+		syndication
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
+	GUID        string `xml:"guid"`
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
 	PubDate     string `xml:"pubDate"`
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+
+	Enclosure      []RSSEnclosure `xml:"enclosure"`
+	ITunesDuration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	MediaContent   []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroup     []MediaContent `xml:"http://search.yahoo.com/mrss/ group>content"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating http request: %w", err)
-	}
-	client := &http.Client{}
-	req.Header.Add("User-Agent", "gator")
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching rss from %s: %w", feedURL, err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-	decoder := xml.NewDecoder(resp.Body)
+func parseRSS(data []byte) (*Feed, error) {
 	var rss RSSFeed
-	if err := decoder.Decode(&rss); err != nil {
+	if err := xml.Unmarshal(data, &rss); err != nil {
 		return nil, fmt.Errorf("error parsing rss feed: %w", err)
 	}
 
-	rss.Channel.Title = html.UnescapeString(rss.Channel.Title)
-	rss.Channel.Description = html.UnescapeString(rss.Channel.Description)
+	feed := &Feed{
+		Format:      FormatRSS,
+		Title:       html.UnescapeString(rss.Channel.Title),
+		Link:        firstNonEmpty(rss.Channel.Links...),
+		Description: strings.TrimSpace(rss.Channel.Description),
+		Image:       firstNonEmpty(rss.Channel.Image.URL, rss.Channel.ITunesImage.Href),
+		Items:       make([]Item, 0, len(rss.Channel.Item)),
+		Schedule: Schedule{
+			TTL:          parseTTL(rss.Channel.TTL),
+			UpdatePeriod: rss.Channel.period(),
+			SkipHours:    parseSkipHours(rss.Channel.SkipHours),
+			SkipDays:     parseSkipDays(rss.Channel.SkipDays),
+		},
+	}
 
-	for i := range len(rss.Channel.Item) {
-		item := rss.Channel.Item[i]
-		item.Title = html.UnescapeString(item.Title)
-		item.Description = html.UnescapeString(item.Description)
-		rss.Channel.Item[i] = item
+	for _, rssItem := range rss.Channel.Item {
+		item := Item{
+			ID:          strings.TrimSpace(rssItem.GUID),
+			Title:       html.UnescapeString(rssItem.Title),
+			Link:        rssItem.Link,
+			Description: strings.TrimSpace(rssItem.Description),
+			Content:     strings.TrimSpace(rssItem.Content),
+		}
+		item.Published = parseOptionalDate(rssItem.PubDate)
+		if item.Published.IsZero() {
+			item.Published = parseOptionalDate(rssItem.DCDate)
+		}
+		for _, enclosure := range rssItem.Enclosure {
+			item.Enclosures = appendEnclosure(item.Enclosures, Enclosure{
+				URL:      enclosure.URL,
+				Type:     enclosure.Type,
+				Length:   parseLength(enclosure.Length),
+				Duration: parseDuration(rssItem.ITunesDuration),
+			})
+		}
+		for _, media := range append(rssItem.MediaContent, rssItem.MediaGroup...) {
+			item.Enclosures = appendEnclosure(item.Enclosures, Enclosure{
+				URL:      media.URL,
+				Type:     media.Type,
+				Length:   parseLength(media.FileSize),
+				Duration: parseDuration(media.Duration),
+			})
+		}
+		// podcast episodes often come without a link of their own
+		if item.Link == "" && len(item.Enclosures) > 0 {
+			item.Link = item.Enclosures[0].URL
+		}
+		feed.Items = append(feed.Items, item)
 	}
 
-	return &rss, nil
+	return feed, nil
+}
+
+// firstNonEmpty returns the first of the values which is not blank.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
 }
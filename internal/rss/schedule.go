@@ -0,0 +1,81 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule holds the hints a publisher gives on how often a feed should be polled.
+type Schedule struct {
+	// TTL is the time a fetched feed may be cached before it is refreshed (RSS <ttl>).
+	TTL time.Duration
+	// UpdatePeriod is the expected time between two updates (sy:updatePeriod / sy:updateFrequency).
+	UpdatePeriod time.Duration
+	// SkipHours are the hours (GMT) in which the feed should not be fetched.
+	SkipHours []int
+	// SkipDays are the weekdays on which the feed should not be fetched.
+	SkipDays []time.Weekday
+}
+
+// syndication is the RSS 1.0 syndication module used by RSS 1.0 and 2.0 channels.
+type syndication struct {
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+func (s syndication) period() time.Duration {
+	var base time.Duration
+	switch strings.ToLower(strings.TrimSpace(s.UpdatePeriod)) {
+	case "hourly":
+		base = time.Hour
+	case "daily":
+		base = 24 * time.Hour
+	case "weekly":
+		base = 7 * 24 * time.Hour
+	case "monthly":
+		base = 30 * 24 * time.Hour
+	case "yearly":
+		base = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+	frequency, err := strconv.Atoi(strings.TrimSpace(s.UpdateFrequency))
+	if err != nil || frequency < 1 {
+		frequency = 1
+	}
+	return base / time.Duration(frequency)
+}
+
+func parseTTL(value string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || minutes < 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func parseSkipHours(values []string) []int {
+	var hours []int
+	for _, value := range values {
+		hour, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || hour < 0 || hour > 24 {
+			continue
+		}
+		// some publishers count from 1 to 24
+		hours = append(hours, hour%24)
+	}
+	return hours
+}
+
+func parseSkipDays(values []string) []time.Weekday {
+	var days []time.Weekday
+	for _, value := range values {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(strings.TrimSpace(value), day.String()) {
+				days = append(days, day)
+			}
+		}
+	}
+	return days
+}
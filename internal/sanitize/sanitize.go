@@ -0,0 +1,247 @@
+package sanitize
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// dropped elements are removed together with their content.
+var dropped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Textarea: true,
+	atom.Select:   true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Head:     true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Base:     true,
+	atom.Title:    true,
+}
+
+// allowed elements are kept with the given attributes. All other elements are
+// replaced by their content.
+var allowed = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        {"cite", "datetime"},
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        {"cite", "datetime"},
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Samp:       nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strike:     nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Time:       {"datetime"},
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+	atom.Var:        nil,
+}
+
+// urlAttributes hold urls which are resolved and checked for a safe scheme.
+var urlAttributes = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+var safeSchemes = map[string]bool{
+	"":       true,
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// trackers are url fragments of well known tracking pixels which do not
+// announce their size.
+var trackers = []string{
+	"feeds.feedburner.com/~r/",
+	"feedproxy.google.com/~r/",
+	"pixel.wp.com/",
+	"stats.wordpress.com/",
+	"/~ff/",
+	"pi.feedsportal.com/",
+	"www.google-analytics.com/",
+}
+
+// HTML removes everything but a safe allowlist of tags and attributes from the
+// html fragment. Scripts, frames, event handlers and tracking pixels are
+// dropped, relative links and images are resolved against baseURL.
+func HTML(s, baseURL string) string {
+	container := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), container)
+	if err != nil {
+		return html.EscapeString(s)
+	}
+	for _, node := range nodes {
+		container.AppendChild(node)
+	}
+
+	var base *url.URL
+	if u, err := url.Parse(baseURL); err == nil && u.IsAbs() {
+		base = u
+	}
+	clean(container, base)
+
+	var b strings.Builder
+	for c := container.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return html.EscapeString(s)
+		}
+	}
+	return b.String()
+}
+
+func clean(parent *html.Node, base *url.URL) {
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			keep, ok := allowed[c.DataAtom]
+			switch {
+			case dropped[c.DataAtom]:
+				parent.RemoveChild(c)
+			case !ok:
+				// unknown elements are unwrapped so their text survives
+				clean(c, base)
+				for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+					c.RemoveChild(gc)
+					parent.InsertBefore(gc, c)
+				}
+				parent.RemoveChild(c)
+			default:
+				c.Attr = attributes(c.Attr, keep, base)
+				if c.DataAtom == atom.Img && (attr(c, "src") == "" || isTrackingPixel(c)) {
+					parent.RemoveChild(c)
+					break
+				}
+				clean(c, base)
+			}
+		default:
+			// comments, doctypes and the like
+			parent.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+func attributes(attrs []html.Attribute, keep []string, base *url.URL) []html.Attribute {
+	var result []html.Attribute
+	for _, a := range attrs {
+		if a.Namespace != "" || !contains(keep, a.Key) {
+			continue
+		}
+		if urlAttributes[a.Key] {
+			resolved, ok := resolve(a.Val, base)
+			if !ok {
+				continue
+			}
+			a.Val = resolved
+		}
+		result = append(result, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+	return result
+}
+
+// resolve makes the url absolute and reports whether it uses a safe scheme.
+func resolve(value string, base *url.URL) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if !safeSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// isTrackingPixel detects images of at most one pixel and known trackers.
+func isTrackingPixel(n *html.Node) bool {
+	for _, key := range []string{"width", "height"} {
+		if size, err := strconv.Atoi(strings.TrimSuffix(attr(n, key), "px")); err == nil && size <= 1 {
+			return true
+		}
+	}
+	src := attr(n, "src")
+	for _, tracker := range trackers {
+		if strings.Contains(src, tracker) {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	const base = "https://example.com/blog/post.html"
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "hello <b>world</b>", "hello <b>world</b>"},
+		{"script", `<p>hi<script>alert(1)</script></p>`, "<p>hi</p>"},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"javascript href mixed case", `<a href=" JaVaScRiPt:alert(1)">x</a>`, "<a>x</a>"},
+		{"javascript href entity encoded", `<a href="&#106;avascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"javascript href with control character", "<a href=\"java\tscript:alert(1)\">x</a>", "<a>x</a>"},
+		{"data image", `<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="x">`, ""},
+		{"event handlers", `<p onclick="alert(1)" onmouseover="alert(2)">x</p>`, "<p>x</p>"},
+		{"event handler on image", `<img src="/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png"/>`},
+		{"style attribute", `<span style="color:red">x</span>`, "<span>x</span>"},
+		{"svg", `<p>a<svg><script>alert(1)</script><a href="/x">y</a></svg>b</p>`, "<p>ab</p>"},
+		{"svg onload", `<svg onload="alert(1)"/>x`, "x"},
+		{"noscript", `<noscript><img src="/a.png" onerror="alert(1)"></noscript>x`, "x"},
+		{"iframe", `<iframe src="https://evil.example"></iframe>x`, "x"},
+		{"unknown element is unwrapped", `<custom-tag>x</custom-tag>`, "x"},
+		{"comment", `a<!-- secret -->b`, "ab"},
+		{"relative link", `<a href="other.html">x</a>`, `<a href="https://example.com/blog/other.html">x</a>`},
+		{"relative image", `<img src="../img/a.png" alt="a">`, `<img src="https://example.com/img/a.png" alt="a"/>`},
+		{"mailto", `<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com">x</a>`},
+		{"pixel by width", `<img src="https://example.com/a.gif" width="1" height="1">`, ""},
+		{"pixel by px height", `<img src="https://example.com/a.gif" height="0px">`, ""},
+		{"feedburner pixel", `<img src="http://feeds.feedburner.com/~r/example/~4/abc">`, ""},
+		{"wordpress pixel", `<img src="https://pixel.wp.com/b.gif?host=example.com">`, ""},
+		{"image without src", `<img alt="x">`, ""},
+		{"large image", `<img src="/a.png" width="640">`, `<img src="https://example.com/a.png" width="640"/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.in, base); got != tt.want {
+				t.Errorf("HTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLWithoutBase(t *testing.T) {
+	got := HTML(`<a href="/x" onclick="alert(1)">x</a><a href="javascript:alert(1)">y</a>`, "")
+	want := `<a href="/x">x</a><a>y</a>`
+	if got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"github.com/spossner/gator/internal/rss"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultInterval = time.Hour
+	MinInterval     = 5 * time.Minute
+	MaxInterval     = 24 * time.Hour
+	MaxBackoff      = 7 * 24 * time.Hour
+)
+
+// NextInterval adapts the polling interval of a feed to the number of new posts
+// found by the last fetch. Feeds producing several posts per fetch are polled
+// more often, feeds without new posts less often. The publisher's ttl and update
+// period are respected as lower bound.
+func NextInterval(current time.Duration, newPosts int, hints rss.Schedule) time.Duration {
+	if current <= 0 {
+		current = DefaultInterval
+	}
+	next := current
+	switch {
+	case newPosts == 0:
+		next = current * 3 / 2
+	case newPosts > 1:
+		next = current / 2
+	}
+
+	lower := min(max(MinInterval, hints.TTL, hints.UpdatePeriod), MaxInterval)
+	return min(max(next, lower), MaxInterval)
+}
+
+// NextFetch returns the point in time a feed is due again. It is the given
+// interval after now, moved forward to the next hour not excluded by the skip
+// hours and skip days of the feed.
+func NextFetch(now time.Time, interval time.Duration, hints rss.Schedule) time.Time {
+	next := now.Add(interval).UTC()
+	// a week has 168 hours - if every hour is skipped the hints are ignored
+	for range 7 * 24 {
+		if !skipped(next, hints) {
+			return next
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return now.Add(interval).UTC()
+}
+
+// Backoff returns the delay before a feed is retried after the given number of
+// consecutive failures. The delay doubles with every failure.
+func Backoff(interval time.Duration, failures int) time.Duration {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	delay := interval
+	for range failures {
+		delay *= 2
+		if delay >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return delay
+}
+
+func skipped(t time.Time, hints rss.Schedule) bool {
+	return slices.Contains(hints.SkipHours, t.Hour()) || slices.Contains(hints.SkipDays, t.Weekday())
+}
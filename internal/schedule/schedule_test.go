@@ -0,0 +1,85 @@
+package schedule
+
+import (
+	"github.com/spossner/gator/internal/rss"
+	"testing"
+	"time"
+)
+
+func TestNextInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  time.Duration
+		newPosts int
+		hints    rss.Schedule
+		want     time.Duration
+	}{
+		{"unset interval starts at default", 0, 1, rss.Schedule{}, DefaultInterval},
+		{"one new post keeps interval", 2 * time.Hour, 1, rss.Schedule{}, 2 * time.Hour},
+		{"no new posts slows down", 2 * time.Hour, 0, rss.Schedule{}, 3 * time.Hour},
+		{"several new posts speed up", 2 * time.Hour, 5, rss.Schedule{}, time.Hour},
+		{"lower bound", 6 * time.Minute, 10, rss.Schedule{}, MinInterval},
+		{"upper bound", 20 * time.Hour, 0, rss.Schedule{}, MaxInterval},
+		{"ttl is a lower bound", time.Hour, 5, rss.Schedule{TTL: 90 * time.Minute}, 90 * time.Minute},
+		{"update period is a lower bound", time.Hour, 1, rss.Schedule{UpdatePeriod: 2 * time.Hour}, 2 * time.Hour},
+		{"larger of ttl and update period", time.Hour, 1, rss.Schedule{TTL: 3 * time.Hour, UpdatePeriod: 2 * time.Hour}, 3 * time.Hour},
+		{"hints are capped", time.Hour, 1, rss.Schedule{UpdatePeriod: 7 * 24 * time.Hour}, MaxInterval},
+		{"small ttl is ignored", time.Hour, 1, rss.Schedule{TTL: time.Minute}, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextInterval(tt.current, tt.newPosts, tt.hints); got != tt.want {
+				t.Errorf("NextInterval(%v, %d, %+v) = %v, want %v", tt.current, tt.newPosts, tt.hints, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextFetch(t *testing.T) {
+	// a wednesday
+	now := time.Date(2024, 2, 7, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		interval time.Duration
+		hints    rss.Schedule
+		want     time.Time
+	}{
+		{"no hints", time.Hour, rss.Schedule{}, now.Add(time.Hour)},
+		{"skipped hour moves to the next hour", time.Hour, rss.Schedule{SkipHours: []int{11}}, time.Date(2024, 2, 7, 12, 0, 0, 0, time.UTC)},
+		{"several skipped hours", time.Hour, rss.Schedule{SkipHours: []int{11, 12, 13}}, time.Date(2024, 2, 7, 14, 0, 0, 0, time.UTC)},
+		{"skipped hours wrap around midnight", 13 * time.Hour, rss.Schedule{SkipHours: []int{23, 0, 1}}, time.Date(2024, 2, 8, 2, 0, 0, 0, time.UTC)},
+		{"skipped day", time.Hour, rss.Schedule{SkipDays: []time.Weekday{time.Wednesday}}, time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC)},
+		{"skipped weekend", 3 * 24 * time.Hour, rss.Schedule{SkipDays: []time.Weekday{time.Saturday, time.Sunday}}, time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC)},
+		{"everything skipped ignores hints", time.Hour, rss.Schedule{SkipDays: []time.Weekday{0, 1, 2, 3, 4, 5, 6}}, now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextFetch(now, tt.interval, tt.hints); !got.Equal(tt.want) {
+				t.Errorf("NextFetch(%v, %v, %+v) = %v, want %v", now, tt.interval, tt.hints, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", time.Hour, 0, time.Hour},
+		{"first failure doubles", time.Hour, 1, 2 * time.Hour},
+		{"third failure", time.Hour, 3, 8 * time.Hour},
+		{"unset interval uses default", 0, 1, 2 * DefaultInterval},
+		{"capped", time.Hour, 10, MaxBackoff},
+		{"many failures do not overflow", time.Hour, 1000, MaxBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(tt.interval, tt.failures); got != tt.want {
+				t.Errorf("Backoff(%v, %d) = %v, want %v", tt.interval, tt.failures, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("invalid url")
+
+// trackingParams are query parameters added by analytics tools. They do not
+// change the resource a url points to.
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"mc_cid": true,
+	"mc_eid": true,
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize cleans up a url for storing and fetching it. The scheme defaults
+// to https, scheme and host are lower cased, default ports, fragments and
+// tracking parameters are removed.
+func Normalize(raw string) (string, error) {
+	u, err := parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// Key returns the identity of a url. Urls which only differ in their scheme,
+// a www. prefix, a trailing slash, the order of the query parameters or in the
+// details removed by Normalize share the same key.
+func Key(raw string) (string, error) {
+	u, err := parse(raw)
+	if err != nil {
+		return "", err
+	}
+	host := strings.TrimPrefix(u.Host, "www.")
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	key := host + path
+	if u.RawQuery != "" {
+		query := strings.Split(u.RawQuery, "&")
+		sort.Strings(query)
+		key += "?" + strings.Join(query, "&")
+	}
+	return key, nil
+}
+
+// StripTracking removes tracking parameters from the url. Urls which cannot be
+// parsed are returned unchanged.
+func StripTracking(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	stripTracking(u)
+	return u.String()
+}
+
+func parse(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("%w: empty url", ErrInvalidURL)
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return nil, fmt.Errorf("%w: unsupported scheme %s", ErrInvalidURL, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: missing host in %s", ErrInvalidURL, raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+	if u.Port() == defaultPorts[u.Scheme] {
+		// only the port is cut off - Hostname would drop the brackets of ipv6 hosts
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	stripTracking(u)
+	return u, nil
+}
+
+func stripTracking(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	// the raw query is filtered instead of re-encoding url.Values to keep the
+	// order and encoding of the remaining parameters
+	var kept []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		name = strings.ToLower(name)
+		if trackingParams[name] || strings.HasPrefix(name, "utm_") {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	u.ForceQuery = false
+}
@@ -0,0 +1,102 @@
+package urlnorm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"unchanged", "https://example.com/feed.xml", "https://example.com/feed.xml"},
+		{"default scheme", "example.com/feed", "https://example.com/feed"},
+		{"surrounding space", "  https://example.com/feed  ", "https://example.com/feed"},
+		{"lower case scheme and host", "HTTPS://Example.COM/Feed", "https://example.com/Feed"},
+		{"default http port", "http://example.com:80/feed", "http://example.com/feed"},
+		{"default https port", "https://example.com:443/feed", "https://example.com/feed"},
+		{"other port", "https://example.com:8443/feed", "https://example.com:8443/feed"},
+		{"https port on http", "http://example.com:443/feed", "http://example.com:443/feed"},
+		{"ipv6 default port", "http://[::1]:80/x", "http://[::1]/x"},
+		{"ipv6 other port", "http://[::1]:8080/x", "http://[::1]:8080/x"},
+		{"fragment", "https://example.com/feed#top", "https://example.com/feed"},
+		{"tracking params", "https://example.com/feed?utm_source=x&id=1&fbclid=y", "https://example.com/feed?id=1"},
+		{"only tracking params", "https://example.com/feed?utm_source=x", "https://example.com/feed"},
+		{"upper case tracking param", "https://example.com/feed?UTM_Medium=x&a=b", "https://example.com/feed?a=b"},
+		{"query order and encoding kept", "https://example.com/feed?b=%2F&a=1", "https://example.com/feed?b=%2F&a=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if err != nil {
+				t.Fatalf("Normalize(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	for _, in := range []string{"", "   ", "ftp://example.com/feed", "https://", "https://exa mple.com/%zz"} {
+		if _, err := Normalize(in); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Normalize(%q) error = %v, want ErrInvalidURL", in, err)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	same := [][]string{
+		{"https://example.com/feed", "http://example.com/feed", "example.com/feed", "https://www.example.com/feed/", "HTTPS://EXAMPLE.COM:443/feed#x"},
+		{"https://example.com/feed?a=1&b=2", "https://example.com/feed?b=2&a=1", "https://example.com/feed/?a=1&utm_source=x&b=2"},
+		{"https://example.com", "https://example.com/", "www.example.com"},
+		{"http://[::1]:80/x", "https://[::1]/x"},
+	}
+	for _, urls := range same {
+		want, err := Key(urls[0])
+		if err != nil {
+			t.Fatalf("Key(%q) error: %v", urls[0], err)
+		}
+		for _, u := range urls[1:] {
+			if got, err := Key(u); err != nil || got != want {
+				t.Errorf("Key(%q) = %q, %v, want %q", u, got, err, want)
+			}
+		}
+	}
+
+	different := [][2]string{
+		{"https://example.com/feed", "https://example.com/Feed"},
+		{"https://example.com/feed", "https://example.com:8443/feed"},
+		{"https://example.com/feed?a=1", "https://example.com/feed?a=2"},
+		{"https://blog.example.com/feed", "https://example.com/feed"},
+	}
+	for _, pair := range different {
+		a, _ := Key(pair[0])
+		b, _ := Key(pair[1])
+		if a == b {
+			t.Errorf("Key(%q) = Key(%q) = %q, want different keys", pair[0], pair[1], a)
+		}
+	}
+}
+
+func TestStripTracking(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://example.com/post?utm_source=rss&utm_medium=feed", "https://example.com/post"},
+		{"https://example.com/post?id=1&gclid=x#comments", "https://example.com/post?id=1#comments"},
+		{"https://example.com/post?mc_cid=1&mc_eid=2&p=3", "https://example.com/post?p=3"},
+		{"/relative?utm_source=x&a=1", "/relative?a=1"},
+		{"https://example.com/post", "https://example.com/post"},
+		{"%zz", "%zz"},
+	}
+	for _, tt := range tests {
+		if got := StripTracking(tt.in); got != tt.want {
+			t.Errorf("StripTracking(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -31,11 +31,25 @@ func main() {
 	cmds.register("users", handlerUsers)
 	cmds.register("agg", handlerAgg)
 	cmds.register("feeds", handlerFeeds)
+	cmds.register("enablefeed", handlerEnableFeed)
+	cmds.register("feed-history", handlerFeedHistory)
 	cmds.register("addfeed", withAuthentication(handlerAddFeed))
 	cmds.register("follow", withAuthentication(handlerFollow))
 	cmds.register("unfollow", withAuthentication(handlerUnfollow))
 	cmds.register("following", withAuthentication(handlerFollowing))
 	cmds.register("browse", withAuthentication(handlerBrowse))
+	cmds.register("tag", withAuthentication(handlerTag))
+	cmds.register("untag", withAuthentication(handlerUntag))
+	cmds.register("tags", withAuthentication(handlerTags))
+	cmds.register("folder", withAuthentication(handlerFolder))
+	cmds.register("search", withAuthentication(handlerSearch))
+	cmds.register("saved", withAuthentication(handlerSaved))
+	cmds.register("save", withAuthentication(handlerSave))
+	cmds.register("unsave", withAuthentication(handlerUnsave))
+	cmds.register("mark-read", withAuthentication(handlerMarkRead))
+	cmds.register("import", withAuthentication(handlerImport))
+	cmds.register("export", withAuthentication(handlerExport))
+	cmds.register("download", handlerDownload)
 
 	args := os.Args[1:]
 	if len(args) < 1 {
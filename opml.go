@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spossner/gator/internal/database"
+	"github.com/spossner/gator/internal/opml"
+	"github.com/spossner/gator/internal/urlnorm"
+	"io"
+	"os"
+)
+
+func handlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("missing opml file")
+	}
+	f, err := os.Open(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", cmd.args[0], err)
+	}
+	defer func() { _ = f.Close() }()
+	doc, err := opml.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("error fetching follows for user %s: %w", user.Name, err)
+	}
+	following := make(map[uuid.UUID]bool, len(follows))
+	for _, follow := range follows {
+		following[follow.FeedID] = true
+	}
+
+	// feeds are imported without fetching them - agg fills in their metadata
+	var created, followed, skipped, failed int
+	folders := make(map[string]uuid.NullUUID)
+	for _, entry := range doc.Feeds() {
+		feed, isNew, err := importFeed(s, user, entry)
+		if err != nil {
+			failed++
+			fmt.Printf("error importing %s: %v\n", entry.XMLURL, err)
+			continue
+		}
+		if isNew {
+			created++
+		}
+		if following[feed.ID] {
+			skipped++
+			continue
+		}
+		folderID, ok := folders[entry.Category]
+		if !ok && entry.Category != "" {
+			folder, err := s.db.GetOrCreateFolder(context.Background(), database.GetOrCreateFolderParams{
+				UserID: user.ID,
+				Name:   entry.Category,
+			})
+			if err != nil {
+				return fmt.Errorf("error creating folder %s: %w", entry.Category, err)
+			}
+			folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+			folders[entry.Category] = folderID
+		}
+		_, err = s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+			UserID:   user.ID,
+			FeedID:   feed.ID,
+			FolderID: folderID,
+		})
+		if err != nil {
+			failed++
+			fmt.Printf("error following %s: %v\n", feed.Url, err)
+			continue
+		}
+		following[feed.ID] = true
+		followed++
+		fmt.Printf("* %s\n", feed.Name)
+	}
+
+	fmt.Printf("created %d feed(s), followed %d, %d already followed, %d failed\n", created, followed, skipped, failed)
+	return nil
+}
+
+// importFeed finds the feed of the outline or creates it if it is unknown.
+func importFeed(s *state, user database.User, entry opml.Feed) (database.Feed, bool, error) {
+	feedURL, err := urlnorm.Normalize(entry.XMLURL)
+	if err != nil {
+		return database.Feed{}, false, err
+	}
+	feed, err := getFeedByUrl(s, feedURL)
+	if err == nil {
+		return feed, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, false, err
+	}
+
+	canonicalURL, err := urlnorm.Key(feedURL)
+	if err != nil {
+		return database.Feed{}, false, err
+	}
+	name := entry.Title
+	if name == "" {
+		name = feedURL
+	}
+	feed, err = s.db.CreateFeed(context.Background(), database.CreateFeedParams{
+		Name:         name,
+		Url:          feedURL,
+		CanonicalUrl: canonicalURL,
+		UserID:       user.ID,
+		SiteUrl:      optionalString(entry.HTMLURL),
+	})
+	if err != nil {
+		return database.Feed{}, false, fmt.Errorf("error creating feed entry: %w", err)
+	}
+	return feed, true, nil
+}
+
+func handlerExport(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	tag := flags.String("tag", "", "only export feeds with posts carrying this tag")
+	if err := parseFlags(flags, cmd.args, 1); err != nil {
+		return err
+	}
+	tagID, err := tagFilter(s, user, *tag)
+	if err != nil {
+		return err
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{
+		UserID: user.ID,
+		TagID:  tagID,
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching follows for user %s: %w", user.Name, err)
+	}
+
+	feeds := make([]opml.Feed, 0, len(follows))
+	for _, follow := range follows {
+		feeds = append(feeds, opml.Feed{
+			Title:    follow.FeedName,
+			XMLURL:   follow.FeedUrl,
+			HTMLURL:  follow.FeedSiteUrl.String,
+			Category: follow.FolderName.String,
+		})
+	}
+	doc := opml.New(fmt.Sprintf("gator feeds of %s", user.Name), feeds)
+
+	var w io.Writer = os.Stdout
+	if flags.NArg() > 0 {
+		f, err := os.Create(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", flags.Arg(0), err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := doc.Write(w); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		fmt.Printf("exported %d feed(s) to %s\n", len(feeds), flags.Arg(0))
+	}
+	return nil
+}
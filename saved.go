@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spossner/gator/internal/database"
+	"strconv"
+	"time"
+)
+
+func handlerSaved(s *state, cmd command, user database.User) error {
+	var limit int32 = 10
+	if len(cmd.args) > 0 {
+		if i, err := strconv.Atoi(cmd.args[0]); err == nil {
+			limit = int32(i)
+		}
+	}
+
+	posts, err := s.db.GetSavedPosts(context.Background(), database.GetSavedPostsParams{
+		UserID: user.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching saved posts for user %s: %w", user.Name, err)
+	}
+	if len(posts) == 0 {
+		fmt.Println("no saved posts - star posts in browse with *")
+		return nil
+	}
+	for _, post := range posts {
+		fmt.Printf("* %s | %s\n  %s\n  id %s, saved %s\n", post.FeedName, post.Title, post.Url, shortID(post.ID), post.SavedAt.Format(time.DateTime))
+	}
+	return nil
+}
+
+func handlerSave(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("missing post id")
+	}
+	post, err := getPostByPrefix(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	if err := toggleSaved(s, user, post.ID, false); err != nil {
+		return err
+	}
+	fmt.Printf("saved %s\n", post.Title)
+	return nil
+}
+
+func handlerUnsave(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("missing post id")
+	}
+	post, err := getPostByPrefix(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	if err := toggleSaved(s, user, post.ID, true); err != nil {
+		return err
+	}
+	fmt.Printf("removed %s from saved posts\n", post.Title)
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spossner/gator/internal/database"
+	"github.com/spossner/gator/internal/render"
+	"strings"
+	"time"
+)
+
+func handlerSearch(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only search the posts of this feed")
+	since := flags.String("since", "", "only posts published on or after this date (2006-01-02) or within this age (e.g. 30d)")
+	until := flags.String("until", "", "only posts published on or before this date (2006-01-02)")
+	tag := flags.String("tag", "", "only search posts with this tag")
+	followed := flags.Bool("followed", false, "only search feeds you are following")
+	limit := flags.Int("limit", 10, "maximum number of results")
+	plain := flags.Bool("plain", false, "print snippets without colors and styles")
+	if err := flags.Parse(cmd.args); err != nil {
+		return err
+	}
+	// words starting with - exclude terms from the query, only flags are rejected
+	for _, arg := range flags.Args() {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && flags.Lookup(name) != nil {
+			return fmt.Errorf("flag %s must be given before the query", arg)
+		}
+	}
+	query := strings.Join(flags.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		return errors.New("missing search query")
+	}
+
+	tagID, err := tagFilter(s, user, *tag)
+	if err != nil {
+		return err
+	}
+	params := database.SearchPostsParams{
+		Query:        query,
+		TagID:        tagID,
+		FollowedOnly: *followed,
+		UserID:       user.ID,
+		Limit:        int32(*limit),
+	}
+	if *feedURL != "" {
+		feed, err := getFeedByUrl(s, *feedURL)
+		if err != nil {
+			return fmt.Errorf("unknown feed %s: %w", *feedURL, err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if *since != "" {
+		day, err := parseDay(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: day, Valid: true}
+	}
+	if *until != "" {
+		day, err := time.Parse(time.DateOnly, *until)
+		if err != nil {
+			return fmt.Errorf("invalid date %s - use e.g. 2006-01-02", *until)
+		}
+		params.Until = sql.NullTime{Time: day, Valid: true}
+	}
+
+	results, err := s.db.SearchPosts(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("error searching posts: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Printf("no posts found for %q\n", query)
+		return nil
+	}
+
+	ansi := !*plain && colorsEnabled()
+	for i, result := range results {
+		publishedAt := result.PublishedAt.Time
+		if !result.PublishedAt.Valid {
+			publishedAt = result.CreatedAt.Time
+		}
+		snippet := result.Snippet
+		if !ansi {
+			// keep the matches visible without styles
+			snippet = strings.NewReplacer("<b>", "*", "</b>", "*").Replace(snippet)
+		}
+		fmt.Printf("%d) %s | %s\n   %s | id %s | %s\n", i+1, strings.ToUpper(result.FeedName), result.Title, publishedAt.Format(time.DateTime), shortID(result.ID), result.Url)
+		fmt.Println(render.HTML(snippet, render.Options{Width: terminalWidth(), ANSI: ansi}))
+		fmt.Println()
+	}
+	return nil
+}
+
+// parseDay parses a date or an age like 30d relative to today.
+func parseDay(s string) (time.Time, error) {
+	if day, err := time.Parse(time.DateOnly, s); err == nil {
+		return day, nil
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s - use e.g. 2006-01-02 or 30d", s)
+	}
+	return time.Now().UTC().Add(-age), nil
+}
@@ -0,0 +1,14 @@
+-- name: UpsertEnclosure :exec
+INSERT INTO enclosures (post_id, url, mime_type, length, duration)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (post_id, url) DO UPDATE
+SET mime_type = excluded.mime_type,
+    length = excluded.length,
+    duration = excluded.duration,
+    updated_at = current_timestamp;
+
+-- name: GetEnclosuresByPosts :many
+SELECT *
+FROM enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY created_at;
@@ -0,0 +1,21 @@
+-- name: CreateFeedFetch :exec
+-- The fetch is recorded when it is finished, its start is derived from the duration.
+INSERT INTO feed_fetches (feed_id, started_at, status_code, bytes, items_seen, posts_inserted, posts_updated, duplicates, error)
+VALUES (
+    sqlc.arg(feed_id),
+    current_timestamp - make_interval(secs => sqlc.arg(duration_seconds)::float8),
+    sqlc.arg(status_code),
+    sqlc.arg(bytes),
+    sqlc.arg(items_seen),
+    sqlc.arg(posts_inserted),
+    sqlc.arg(posts_updated),
+    sqlc.arg(duplicates),
+    sqlc.arg(error)
+);
+
+-- name: GetFeedFetches :many
+SELECT *
+FROM feed_fetches
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
@@ -1,7 +1,7 @@
 -- name: CreateFeedFollow :one
 WITH ff as (
-    INSERT INTO feed_follows (user_id, feed_id)
-    VALUES ($1, $2)
+    INSERT INTO feed_follows (user_id, feed_id, folder_id)
+    VALUES ($1, $2, $3)
     RETURNING *
 )
 SELECT
@@ -33,8 +33,24 @@ select
     ff.*,
     f.name as feed_name,
     f.url as feed_url,
+    f.site_url as feed_site_url,
+    fo.name as folder_name,
     u.name as user_name
 from feed_follows ff
 join users u on ff.user_id = u.id
 join feeds f on ff.feed_id = f.id
-where ff.user_id = $1;
\ No newline at end of file
+left join folders fo on ff.folder_id = fo.id
+where ff.user_id = sqlc.arg(user_id)
+  -- optionally only feeds with posts carrying the tag
+  and (sqlc.narg(tag_id)::uuid is null or exists (
+      select 1
+      from post_tags pt
+      join posts p on pt.post_id = p.id
+      where pt.tag_id = sqlc.narg(tag_id) and p.feed_id = ff.feed_id
+  ))
+order by fo.name nulls first, f.name;
+
+-- name: MoveFeedFollow :execrows
+UPDATE feed_follows
+SET folder_id = $3, updated_at = current_timestamp
+WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
@@ -1,24 +1,102 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (name, url, user_id)
-VALUES ($1, $2, $3)
+INSERT INTO feeds (name, url, canonical_url, user_id, title, site_url, description, image_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetFeeds :many
 SELECT feeds.*, users.name as user_name
 FROM feeds join users on feeds.user_id = users.id;
 
+-- name: GetBrokenFeeds :many
+SELECT feeds.*, users.name as user_name
+FROM feeds join users on feeds.user_id = users.id
+WHERE feeds.failure_count > 0 OR feeds.disabled_at IS NOT NULL
+ORDER BY feeds.disabled_at ASC NULLS LAST, feeds.failure_count DESC;
+
 -- name: GetFeedByUrl :one
+-- Looks up a feed by the canonical form of its url. The url as stored is matched
+-- as well for feeds whose canonical url could not be derived exactly on migration.
 SELECT *
 FROM feeds
-WHERE url = $1;
+WHERE canonical_url = sqlc.arg(canonical_url) OR url = sqlc.arg(url)
+ORDER BY canonical_url = sqlc.arg(canonical_url) DESC
+LIMIT 1;
 
--- name: MarkFeedFetched :exec
+-- name: ClaimNextFeedToFetch :one
+-- Picks the feed which is due for the longest time and marks it fetched. Rows
+-- locked by concurrent claims are skipped so parallel workers never fetch the
+-- same feed. The feed is provisionally rescheduled with its current interval.
 UPDATE feeds
-SET last_fetched_at = current_timestamp, updated_at = current_timestamp
+SET last_fetched_at = current_timestamp,
+    next_fetch_at = current_timestamp + make_interval(secs => fetch_interval),
+    updated_at = current_timestamp
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= current_timestamp)
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ReleaseFeedClaim :exec
+-- Makes a claimed feed due again after its fetch was aborted.
+UPDATE feeds
+SET next_fetch_at = current_timestamp, updated_at = current_timestamp
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
-SELECT *
-FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
\ No newline at end of file
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET fetch_interval = sqlc.arg(fetch_interval),
+    next_fetch_at = current_timestamp + make_interval(secs => sqlc.arg(delay_seconds)::float8),
+    failure_count = 0,
+    last_error = NULL,
+    last_success_at = current_timestamp,
+    updated_at = current_timestamp
+WHERE id = sqlc.arg(id);
+
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET failure_count = failure_count + 1,
+    last_error = sqlc.arg(last_error),
+    next_fetch_at = current_timestamp + make_interval(secs => sqlc.arg(delay_seconds)::float8),
+    disabled_at = CASE WHEN sqlc.arg(disable)::boolean THEN current_timestamp END,
+    updated_at = current_timestamp
+WHERE id = sqlc.arg(id);
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET failure_count = 0,
+    last_error = NULL,
+    disabled_at = NULL,
+    next_fetch_at = NULL,
+    updated_at = current_timestamp
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3, updated_at = current_timestamp
+WHERE id = $1;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2, site_url = $3, description = $4, image_url = $5, updated_at = current_timestamp
+WHERE id = $1;
+
+-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET url = $2, canonical_url = $3, moved_to = NULL, updated_at = current_timestamp
+WHERE id = $1;
+
+-- name: UpdateFeedMovedTo :exec
+UPDATE feeds
+SET moved_to = $2, updated_at = current_timestamp
+WHERE id = $1;
+
+-- name: UpdateFeedScheduleHints :exec
+UPDATE feeds
+SET ttl = $2, update_period = $3, skip_hours = $4, skip_days = $5, updated_at = current_timestamp
+WHERE id = $1;
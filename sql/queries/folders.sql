@@ -0,0 +1,34 @@
+-- name: CreateFolder :one
+INSERT INTO folders (user_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetOrCreateFolder :one
+-- The no-op update makes the existing folder part of the result.
+INSERT INTO folders (user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) DO UPDATE SET name = excluded.name
+RETURNING *;
+
+-- name: GetFolderByName :one
+SELECT *
+FROM folders
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetFoldersForUser :many
+SELECT fo.*, count(ff.id) AS feed_count
+FROM folders fo
+LEFT JOIN feed_follows ff ON ff.folder_id = fo.id
+WHERE fo.user_id = $1
+GROUP BY fo.id
+ORDER BY fo.name;
+
+-- name: RenameFolder :one
+UPDATE folders
+SET name = sqlc.arg(new_name), updated_at = current_timestamp
+WHERE user_id = sqlc.arg(user_id) AND name = sqlc.arg(name)
+RETURNING *;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE user_id = $1 AND name = $2;
@@ -0,0 +1,17 @@
+-- name: MarkPostsRead :exec
+INSERT INTO post_reads (user_id, post_id)
+SELECT sqlc.arg(user_id), unnest(sqlc.arg(post_ids)::uuid[])
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkFollowedPostsRead :execrows
+-- Marks the posts of all followed feeds or of a single feed read, optionally
+-- only those older than the given number of seconds.
+INSERT INTO post_reads (user_id, post_id)
+SELECT ff.user_id, p.id
+FROM posts p
+JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(older_than_seconds)::float8 IS NULL
+       OR COALESCE(p.published_at, p.created_at) < current_timestamp - make_interval(secs => sqlc.narg(older_than_seconds)))
+ON CONFLICT (user_id, post_id) DO NOTHING;
@@ -1,14 +1,58 @@
--- name: CreatePost :one
-INSERT INTO posts (feed_id, title, url, description, published_at)
-VALUES ($1, $2, $3, $4, $5)
-    RETURNING *;
+-- name: UpsertPost :one
+-- Inserts a new post or updates the known post with the same guid if its content
+-- changed. No row is returned if the post is known and unchanged.
+INSERT INTO posts (feed_id, guid, title, url, description, content, raw_description, raw_content, published_at, content_hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    content = excluded.content,
+    raw_description = excluded.raw_description,
+    raw_content = excluded.raw_content,
+    published_at = COALESCE(excluded.published_at, posts.published_at),
+    content_hash = excluded.content_hash,
+    updated_at = current_timestamp
+WHERE posts.content_hash IS DISTINCT FROM excluded.content_hash
+RETURNING *, (xmax = 0)::boolean AS inserted;
 
 -- name: GetPostsByUser :many
-SELECT p.*, f.name, f.url
+-- With unread_only set posts read by the user are skipped - except for the
+-- session_reads, the posts marked read while browsing, to keep pages stable.
+SELECT p.*, f.name, f.url,
+       EXISTS (SELECT 1 FROM saved_posts sp WHERE sp.user_id = ff.user_id AND sp.post_id = p.id) AS saved
 FROM posts p
 JOIN feeds f ON p.feed_id = f.id
 JOIN feed_follows ff on ff.feed_id = f.id
-WHERE ff.user_id = $1
-ORDER BY p.published_at desc
-LIMIT $2
-OFFSET $3;
+WHERE ff.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR ff.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tag_id)::uuid IS NULL
+       OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.tag_id = sqlc.narg(tag_id) AND pt.post_id = p.id))
+  AND (NOT sqlc.arg(unread_only)::boolean
+       OR p.id = ANY(sqlc.arg(session_reads)::uuid[])
+       OR NOT EXISTS (SELECT 1 FROM post_reads pr WHERE pr.user_id = ff.user_id AND pr.post_id = p.id))
+ORDER BY COALESCE(p.published_at, p.created_at) desc
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetPostsByIdPrefix :many
+-- The prefix is compared literally, LIKE would treat % and _ as wildcards.
+SELECT *
+FROM posts
+WHERE left(id::text, length(sqlc.arg(prefix)::text)) = sqlc.arg(prefix)::text
+LIMIT 2;
+
+-- name: AdoptPostGuid :exec
+-- Posts stored before guids were tracked have none. They are matched by url when
+-- their feed lists them again and take over the guid of the item. The content
+-- hash is backfilled as well, so adopting a post does not count as an edit.
+UPDATE posts
+SET guid = sqlc.arg(guid)::text,
+    content_hash = sqlc.arg(content_hash)::text
+WHERE id = (
+    SELECT p.id
+    FROM posts p
+    WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid IS NULL AND p.url = ANY(sqlc.arg(urls)::text[])
+    LIMIT 1
+)
+AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid));
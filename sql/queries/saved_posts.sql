@@ -0,0 +1,18 @@
+-- name: SavePost :exec
+INSERT INTO saved_posts (user_id, post_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnsavePost :execrows
+DELETE FROM saved_posts
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetSavedPosts :many
+SELECT p.*, f.name AS feed_name, sp.saved_at
+FROM saved_posts sp
+JOIN posts p ON sp.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+WHERE sp.user_id = $1
+ORDER BY sp.saved_at DESC
+LIMIT $2
+OFFSET $3;
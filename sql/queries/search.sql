@@ -0,0 +1,42 @@
+-- name: SearchPosts :many
+-- Ranks the posts matching the query and highlights the matches in a snippet of
+-- the post's text. The tsvector expression matches the one of posts_search_idx.
+WITH search AS (
+    SELECT websearch_to_tsquery('english', sqlc.arg(query)::text) AS query
+)
+SELECT p.id,
+       p.title,
+       p.url,
+       p.published_at,
+       p.created_at,
+       f.name AS feed_name,
+       ts_rank(
+           setweight(to_tsvector('english', coalesce(p.title, '')), 'A') ||
+           setweight(to_tsvector('english', coalesce(p.description, '')), 'B') ||
+           setweight(to_tsvector('english', coalesce(p.content, '')), 'C'),
+           search.query
+       )::float8 AS rank,
+       ts_headline(
+           'english',
+           regexp_replace(coalesce(p.content, p.description, ''), '<[^>]*>', ' ', 'g'),
+           search.query,
+           'StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=25, MinWords=10'
+       )::text AS snippet
+FROM posts p
+JOIN feeds f ON p.feed_id = f.id
+CROSS JOIN search
+WHERE (
+    setweight(to_tsvector('english', coalesce(p.title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(p.description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(p.content, '')), 'C')
+) @@ search.query
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(tag_id)::uuid IS NULL
+       OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.tag_id = sqlc.narg(tag_id) AND pt.post_id = p.id))
+  AND (sqlc.narg(since)::date IS NULL OR COALESCE(p.published_at, p.created_at) >= sqlc.narg(since)::date)
+  AND (sqlc.narg(until)::date IS NULL OR COALESCE(p.published_at, p.created_at) < sqlc.narg(until)::date + 1)
+  AND (NOT sqlc.arg(followed_only)::boolean OR EXISTS (
+      SELECT 1 FROM feed_follows ff WHERE ff.feed_id = p.feed_id AND ff.user_id = sqlc.arg(user_id)
+  ))
+ORDER BY rank DESC, COALESCE(p.published_at, p.created_at) DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,44 @@
+-- name: GetOrCreateTag :one
+-- The no-op update makes the existing tag part of the result.
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) DO UPDATE SET name = excluded.name
+RETURNING *;
+
+-- name: GetTagByName :one
+SELECT *
+FROM tags
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetTagsForUser :many
+SELECT t.*, count(pt.post_id) AS post_count
+FROM tags t
+LEFT JOIN post_tags pt ON pt.tag_id = t.id
+WHERE t.user_id = $1
+GROUP BY t.id
+ORDER BY t.name;
+
+-- name: TagPost :exec
+INSERT INTO post_tags (tag_id, post_id)
+VALUES ($1, $2)
+ON CONFLICT (tag_id, post_id) DO NOTHING;
+
+-- name: UntagPost :execrows
+DELETE FROM post_tags
+WHERE tag_id = $1 AND post_id = $2;
+
+-- name: GetPostsByTag :many
+SELECT p.*, f.name AS feed_name
+FROM post_tags pt
+JOIN posts p ON pt.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+WHERE pt.tag_id = $1
+ORDER BY pt.created_at DESC
+LIMIT $2;
+
+-- name: GetTagsByPosts :many
+SELECT pt.post_id, t.name
+FROM post_tags pt
+JOIN tags t ON pt.tag_id = t.id
+WHERE t.user_id = sqlc.arg(user_id) AND pt.post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY t.name;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN etag VARCHAR;
+ALTER TABLE feeds ADD COLUMN last_modified VARCHAR;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN last_modified;
+ALTER TABLE feeds DROP COLUMN etag;
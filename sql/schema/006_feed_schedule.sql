@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN fetch_interval INTEGER NOT NULL DEFAULT 3600; -- seconds
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
+ALTER TABLE feeds DROP COLUMN fetch_interval;
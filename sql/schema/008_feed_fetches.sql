@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE feed_fetches (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    feed_id         uuid NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at      TIMESTAMP NOT NULL,
+    finished_at     TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    status_code     INTEGER,
+    bytes           BIGINT,
+    items_seen      INTEGER NOT NULL DEFAULT 0,
+    posts_inserted  INTEGER NOT NULL DEFAULT 0,
+    duplicates      INTEGER NOT NULL DEFAULT 0,
+    error           VARCHAR
+);
+CREATE INDEX feed_fetches_feed_id_started_at_idx ON feed_fetches (feed_id, started_at DESC);
+
+-- +goose Down
+DROP TABLE feed_fetches;
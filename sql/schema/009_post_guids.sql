@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN guid VARCHAR;
+ALTER TABLE posts ADD COLUMN content_hash VARCHAR;
+-- posts stored before have no guid - they are matched by url and get their guid
+-- the next time their feed lists them (see AdoptPostGuid)
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+ALTER TABLE posts ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+CREATE INDEX posts_url_idx ON posts (url);
+
+ALTER TABLE feed_fetches ADD COLUMN posts_updated INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feed_fetches DROP COLUMN posts_updated;
+
+DROP INDEX posts_url_idx;
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_guid_key;
+ALTER TABLE posts ADD CONSTRAINT posts_url_key UNIQUE (url);
+ALTER TABLE posts DROP COLUMN content_hash;
+ALTER TABLE posts DROP COLUMN guid;
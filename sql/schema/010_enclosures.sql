@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE enclosures (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id     uuid NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url         VARCHAR NOT NULL,
+    mime_type   VARCHAR,
+    length      BIGINT,
+    duration    INTEGER, -- seconds
+    created_at  TIMESTAMP DEFAULT current_timestamp,
+    updated_at  TIMESTAMP DEFAULT current_timestamp,
+    UNIQUE(post_id, url)
+);
+
+-- +goose Down
+DROP TABLE enclosures;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content VARCHAR;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN content;
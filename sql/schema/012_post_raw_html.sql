@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN raw_description VARCHAR;
+ALTER TABLE posts ADD COLUMN raw_content VARCHAR;
+-- posts stored so far were never sanitized
+UPDATE posts SET raw_description = description, raw_content = content;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN raw_content;
+ALTER TABLE posts DROP COLUMN raw_description;
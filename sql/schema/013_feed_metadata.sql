@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN title VARCHAR;
+ALTER TABLE feeds ADD COLUMN site_url VARCHAR;
+ALTER TABLE feeds ADD COLUMN description VARCHAR;
+ALTER TABLE feeds ADD COLUMN image_url VARCHAR;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN image_url;
+ALTER TABLE feeds DROP COLUMN description;
+ALTER TABLE feeds DROP COLUMN site_url;
+ALTER TABLE feeds DROP COLUMN title;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN canonical_url VARCHAR;
+-- approximates urlnorm.Key for existing feeds: drops the scheme, a www. prefix,
+-- the fragment and trailing slashes and lower cases the host
+UPDATE feeds
+SET canonical_url = regexp_replace(lower(substring(r.rest from '^[^/?]*')), '^www\.', '')
+    || regexp_replace(substring(r.rest from '^[^/?]*(.*)$'), '/+(\?|$)', '\1')
+FROM (
+    SELECT id, regexp_replace(regexp_replace(url, '^[a-zA-Z]+://', ''), '#.*$', '') AS rest
+    FROM feeds
+) r
+WHERE feeds.id = r.id;
+-- feeds added twice before keep their raw url - the oldest one gets the canonical url
+UPDATE feeds
+SET canonical_url = url
+WHERE EXISTS (
+    SELECT 1
+    FROM feeds older
+    WHERE older.canonical_url = feeds.canonical_url
+      AND (COALESCE(older.created_at, '-infinity'), older.id) < (COALESCE(feeds.created_at, '-infinity'), feeds.id)
+);
+ALTER TABLE feeds ALTER COLUMN canonical_url SET NOT NULL;
+ALTER TABLE feeds ADD CONSTRAINT feeds_canonical_url_key UNIQUE (canonical_url);
+
+-- +goose Down
+ALTER TABLE feeds DROP CONSTRAINT feeds_canonical_url_key;
+ALTER TABLE feeds DROP COLUMN canonical_url;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE saved_posts (
+    user_id  UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- pruning old posts has to skip the saved ones:
+    -- NOT EXISTS (SELECT 1 FROM saved_posts sp WHERE sp.post_id = posts.id)
+    post_id  UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    saved_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE saved_posts;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- queries have to repeat this expression exactly to use the index
+CREATE INDEX posts_search_idx ON posts USING GIN ((
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(content, '')), 'C')
+));
+
+-- +goose Down
+DROP INDEX posts_search_idx;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE folders (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       VARCHAR NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    updated_at TIMESTAMP DEFAULT current_timestamp,
+    UNIQUE (user_id, name)
+);
+
+-- deleting a folder leaves its feeds unfiled
+ALTER TABLE feed_follows ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+-- categories of imported feeds become folders
+INSERT INTO folders (user_id, name)
+SELECT DISTINCT user_id, category
+FROM feed_follows
+WHERE category IS NOT NULL AND category <> '';
+UPDATE feed_follows ff
+SET folder_id = fo.id
+FROM folders fo
+WHERE fo.user_id = ff.user_id AND fo.name = ff.category;
+ALTER TABLE feed_follows DROP COLUMN category;
+
+-- +goose Down
+ALTER TABLE feed_follows ADD COLUMN category VARCHAR;
+UPDATE feed_follows ff
+SET category = fo.name
+FROM folders fo
+WHERE fo.id = ff.folder_id;
+ALTER TABLE feed_follows DROP COLUMN folder_id;
+DROP TABLE folders;
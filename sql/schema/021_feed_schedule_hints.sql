@@ -0,0 +1,13 @@
+-- +goose Up
+-- the publisher's polling hints of the last full fetch - they still apply
+-- when the feed is not modified
+ALTER TABLE feeds ADD COLUMN ttl INTEGER NOT NULL DEFAULT 0; -- seconds
+ALTER TABLE feeds ADD COLUMN update_period INTEGER NOT NULL DEFAULT 0; -- seconds
+ALTER TABLE feeds ADD COLUMN skip_hours INTEGER[] NOT NULL DEFAULT '{}';
+ALTER TABLE feeds ADD COLUMN skip_days INTEGER[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN skip_days;
+ALTER TABLE feeds DROP COLUMN skip_hours;
+ALTER TABLE feeds DROP COLUMN update_period;
+ALTER TABLE feeds DROP COLUMN ttl;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- the url a feed was permanently redirected to but could not be moved to,
+-- usually because another feed has that url already
+ALTER TABLE feeds ADD COLUMN moved_to VARCHAR;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN moved_to;
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spossner/gator/internal/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func handlerTag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return errors.New("missing post id + label")
+	}
+	post, err := getPostByPrefix(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	label := strings.Join(cmd.args[1:], " ")
+	if err := tagPost(s, user, post.ID, label); err != nil {
+		return err
+	}
+	fmt.Printf("tagged %s with %s\n", post.Title, label)
+	return nil
+}
+
+func handlerUntag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return errors.New("missing post id + label")
+	}
+	post, err := getPostByPrefix(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	label := strings.Join(cmd.args[1:], " ")
+	if err := untagPost(s, user, post.ID, label); err != nil {
+		return err
+	}
+	fmt.Printf("removed tag %s from %s\n", label, post.Title)
+	return nil
+}
+
+// handlerTags lists all tags with the number of tagged posts or the posts
+// tagged with the given label.
+func handlerTags(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("error fetching tags for user %s: %w", user.Name, err)
+		}
+		if len(tags) == 0 {
+			fmt.Println("no tags - tag posts with gator tag <post id> <label>")
+		}
+		for _, tag := range tags {
+			fmt.Printf("* %s (%d post(s))\n", tag.Name, tag.PostCount)
+		}
+		return nil
+	}
+
+	var limit int32 = 10
+	if len(cmd.args) > 1 {
+		if i, err := strconv.Atoi(cmd.args[1]); err == nil {
+			limit = int32(i)
+		}
+	}
+	tag, err := getTagByName(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	posts, err := s.db.GetPostsByTag(context.Background(), database.GetPostsByTagParams{
+		TagID: tag.ID,
+		Limit: limit,
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching posts tagged %s: %w", tag.Name, err)
+	}
+	for _, post := range posts {
+		publishedAt := post.PublishedAt.Time
+		if !post.PublishedAt.Valid {
+			publishedAt = post.CreatedAt.Time
+		}
+		fmt.Printf("* %s | %s\n  %s\n  id %s, %s\n", post.FeedName, post.Title, post.Url, shortID(post.ID), publishedAt.Format(time.DateTime))
+	}
+	return nil
+}
+
+func tagPost(s *state, user database.User, postID uuid.UUID, label string) error {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return errors.New("empty label")
+	}
+	tag, err := s.db.GetOrCreateTag(context.Background(), database.GetOrCreateTagParams{
+		UserID: user.ID,
+		Name:   label,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating tag %s: %w", label, err)
+	}
+	if err := s.db.TagPost(context.Background(), database.TagPostParams{TagID: tag.ID, PostID: postID}); err != nil {
+		return fmt.Errorf("error tagging post %s: %w", shortID(postID), err)
+	}
+	return nil
+}
+
+func untagPost(s *state, user database.User, postID uuid.UUID, label string) error {
+	tag, err := getTagByName(s, user, strings.TrimSpace(label))
+	if err != nil {
+		return err
+	}
+	n, err := s.db.UntagPost(context.Background(), database.UntagPostParams{TagID: tag.ID, PostID: postID})
+	if err != nil {
+		return fmt.Errorf("error removing tag %s from post %s: %w", tag.Name, shortID(postID), err)
+	}
+	if n == 0 {
+		return fmt.Errorf("post %s is not tagged %s", shortID(postID), tag.Name)
+	}
+	return nil
+}
+
+func getTagByName(s *state, user database.User, name string) (database.Tag, error) {
+	tag, err := s.db.GetTagByName(context.Background(), database.GetTagByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Tag{}, fmt.Errorf("unknown tag %s", name)
+	}
+	if err != nil {
+		return database.Tag{}, fmt.Errorf("error fetching tag %s: %w", name, err)
+	}
+	return tag, nil
+}
+
+// getTags loads the labels the user attached to the given posts grouped by post.
+func getTags(s *state, user database.User, postIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	rows, err := s.db.GetTagsByPosts(context.Background(), database.GetTagsByPostsParams{
+		UserID:  user.ID,
+		PostIds: postIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching tags: %w", err)
+	}
+	byPost := make(map[uuid.UUID][]string, len(postIDs))
+	for _, row := range rows {
+		byPost[row.PostID] = append(byPost[row.PostID], row.Name)
+	}
+	return byPost, nil
+}
+
+// tagFilter resolves the optional tag name of a --tag flag.
+func tagFilter(s *state, user database.User, name string) (uuid.NullUUID, error) {
+	if name == "" {
+		return uuid.NullUUID{}, nil
+	}
+	tag, err := getTagByName(s, user, name)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: tag.ID, Valid: true}, nil
+}